@@ -4,33 +4,50 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
 	"strings"
 
+	"golang.org/x/term"
+
+	"pdf-cli/internal/config"
 	"pdf-cli/internal/picker"
+	"pdf-cli/internal/terminal"
 	"pdf-cli/internal/ui"
 	"pdf-cli/internal/viewer"
 )
 
+// Build metadata, stamped by release builds with
+// -ldflags "-X pdf-cli/cmd.Version=... -X pdf-cli/cmd.Commit=...".
+var (
+	Version = "2.0.0"
+	Commit  = ""
+)
+
 // Execute is the main entry point for the CLI application.
 func Execute() {
-	// Handle --help and -h flags
-	if len(os.Args) > 1 {
-		arg := os.Args[1]
-		if arg == "--help" || arg == "-h" {
-			printHelp()
-			return
-		}
-		if arg == "--version" || arg == "-v" {
-			fmt.Println("pdf-cli 2.0.0")
-			return
-		}
+	opts, err := parseArgs(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pdf-cli: %v\nRun 'pdf-cli --help' for usage.\n", err)
+		os.Exit(2)
 	}
+	if opts.help {
+		printHelp()
+		return
+	}
+	if opts.version {
+		printVersion()
+		return
+	}
+
+	viewer.IncludeBlank = opts.blank
 
 	// Determine if user provided an argument
-	hasArg := len(os.Args) > 1
+	hasArg := opts.path != ""
 	arg := "."
 	if hasArg {
-		arg = os.Args[1]
+		arg = opts.path
 	}
 
 	// Expand ~ to home directory
@@ -38,6 +55,69 @@ func Execute() {
 		homeDir, _ := os.UserHomeDir()
 		arg = filepath.Join(homeDir, arg[2:])
 	}
+	if path, page, ok := splitPageSuffix(arg); ok {
+		arg = path
+		if opts.page == 0 {
+			opts.page = page
+		}
+	}
+
+	// "-" is a document piped to stdin, read up front so that --text and
+	// --export work on it too.
+	if arg == viewer.StdinPath {
+		if err := viewer.ReadStdin(); err != nil {
+			fmt.Fprintf(os.Stderr, "pdf-cli: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if opts.search != "" {
+		if err := printSearchResults(opts.search); err != nil {
+			fmt.Fprintf(os.Stderr, "pdf-cli: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.text || opts.json {
+		if !hasArg {
+			flag := "--text"
+			if opts.json {
+				flag = "--json"
+			}
+			fmt.Fprintf(os.Stderr, "pdf-cli: %s requires a file\n", flag)
+			os.Exit(2)
+		}
+		if err := dumpText(arg, opts.pages, opts.json); err != nil {
+			fmt.Fprintf(os.Stderr, "pdf-cli: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.textTo != "" {
+		if !hasArg {
+			fmt.Fprintln(os.Stderr, "pdf-cli: --export-text requires a file")
+			os.Exit(2)
+		}
+		if err := exportText(arg, opts.pages, opts.textTo, opts.width); err != nil {
+			fmt.Fprintf(os.Stderr, "pdf-cli: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.export != "" {
+		if !hasArg {
+			fmt.Fprintln(os.Stderr, "pdf-cli: --export requires a file")
+			os.Exit(2)
+		}
+		if err := exportPages(arg, opts.export, opts.out); err != nil {
+			fmt.Fprintf(os.Stderr, "pdf-cli: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	// When no argument given, show the main menu
 	if !hasArg {
@@ -69,6 +149,7 @@ func Execute() {
 					// User entered a file path directly — try to open it
 					v := viewer.NewDocumentViewer(dir)
 					if err := v.Open(); err != nil {
+						v.Close()
 						fmt.Printf("\n  Error opening file: %v\n  Press any key to go back...\n", err)
 						buf := make([]byte, 1)
 						os.Stdin.Read(buf)
@@ -83,18 +164,38 @@ func Execute() {
 				if !runWithDirectoryPicker(dir) {
 					return
 				}
+			case 2: // Recent Files
+				if !runWithRecentFiles() {
+					return
+				}
 			default:
 				return
 			}
 		}
 	}
 
+	if arg == viewer.StdinPath {
+		if !runFromStdin(opts.page, opts.tabs) {
+			return
+		}
+		arg = "."
+		opts.tabs = nil
+	}
+
 	// Check if argument is a directory or file
 	info, statErr := os.Stat(arg)
 	if statErr != nil {
 		fmt.Printf("Path not found: %s\n", arg)
 		return
 	}
+	if opts.page > 0 && info.IsDir() {
+		fmt.Fprintln(os.Stderr, "pdf-cli: --page requires a file")
+		os.Exit(2)
+	}
+	if len(opts.tabs) > 0 && info.IsDir() {
+		fmt.Fprintln(os.Stderr, "pdf-cli: only files can be opened together")
+		os.Exit(2)
+	}
 
 	// Determine the search directory for "back" functionality
 	searchDir := arg
@@ -105,12 +206,15 @@ func Execute() {
 
 	// Main loop - allows going back to file picker
 	firstFile := true
+	notice := "" // why the last file picked couldn't be opened
 	for {
 		var filePath string
 		var err error
 
-		if isDir || !firstFile {
-			filePath, err = selectFileWithPickerInDir(searchDir)
+		picked := isDir || !firstFile
+		if picked {
+			filePath, err = selectFileWithPickerInDir(searchDir, notice)
+			notice = ""
 			if err != nil {
 				fmt.Printf("File selection cancelled: %v\n", err)
 				return
@@ -125,23 +229,50 @@ func Execute() {
 		}
 
 		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			if picked {
+				notice = filepath.Base(filePath) + " is no longer there"
+				continue
+			}
 			fmt.Printf("File not found at: %s\n", filePath)
 			return
 		}
 
 		ext := strings.ToLower(filepath.Ext(filePath))
-		if ext != ".pdf" && ext != ".epub" && ext != ".docx" && ext != ".html" && ext != ".htm" {
-			fmt.Printf("Unsupported file format: %s\nSupported formats: .pdf, .epub, .docx, .html\n", ext)
+		if ext != ".pdf" && ext != ".epub" && ext != ".docx" && ext != ".html" && ext != ".htm" && ext != ".txt" && ext != ".md" && ext != ".cbz" && ext != ".mobi" && ext != ".azw3" {
+			fmt.Printf("Unsupported file format: %s\nSupported formats: .pdf, .epub, .docx, .html, .txt, .md, .cbz, .mobi, .azw3\n", ext)
 			return
 		}
 
 		v := viewer.NewDocumentViewer(filePath)
 		if err := v.Open(); err != nil {
+			v.Close()
+			if picked {
+				notice = fmt.Sprintf("Can't open %s: %v", filepath.Base(filePath), err)
+				continue
+			}
 			fmt.Printf("Error opening file: %v\n", err)
 			return
 		}
+		// The viewer takes over the screen, so a clamped --page is
+		// reported once it hands the terminal back.
+		var warning string
+		if filePath == arg && opts.page > 0 {
+			if page := v.StartAt(opts.page); page != opts.page {
+				warning = fmt.Sprintf("pdf-cli: %s has only %d pages; opened the last\n", filepath.Base(arg), page)
+			}
+			opts.page = 0
+		}
+		if filePath == arg && len(opts.tabs) > 0 {
+			if err := openTabs(v, opts.tabs); err != nil {
+				v.Close()
+				fmt.Printf("Error opening file: %v\n", err)
+				return
+			}
+			opts.tabs = nil
+		}
 
 		wantBack := v.Run()
+		fmt.Fprint(os.Stderr, warning)
 		if !wantBack {
 			return
 		}
@@ -149,57 +280,334 @@ func Execute() {
 	}
 }
 
+// runFromStdin views the document read from stdin, with any files in tabs
+// beside it, taking keys from the terminal instead. It returns true if the
+// user asked for the file picker, which then lists the current directory.
+func runFromStdin(page int, tabs []string) bool {
+	if err := terminal.UseTTY(); err != nil {
+		fmt.Fprintf(os.Stderr, "pdf-cli: %v\n", err)
+		os.Exit(1)
+	}
+	v := viewer.NewDocumentViewer(viewer.StdinPath)
+	if err := v.Open(); err != nil {
+		fmt.Fprintf(os.Stderr, "pdf-cli: %v\n", err)
+		os.Exit(1)
+	}
+	if err := openTabs(v, tabs); err != nil {
+		v.Close()
+		fmt.Fprintf(os.Stderr, "pdf-cli: %v\n", err)
+		os.Exit(1)
+	}
+	var warning string
+	if page > 0 {
+		if last := v.StartAt(page); last != page {
+			warning = fmt.Sprintf("pdf-cli: standard input has only %d pages; opened the last\n", last)
+		}
+	}
+	wantBack := v.Run()
+	fmt.Fprint(os.Stderr, warning)
+	return wantBack
+}
+
+// openTabs opens paths in tabs after v's document.
+func openTabs(v *viewer.DocumentViewer, paths []string) error {
+	for _, path := range paths {
+		if strings.HasPrefix(path, "~/") {
+			homeDir, _ := os.UserHomeDir()
+			path = filepath.Join(homeDir, path[2:])
+		}
+		if err := v.OpenTab(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// options holds the parsed command-line arguments.
+type options struct {
+	path   string   // file or directory to open ("" shows the main menu)
+	tabs   []string // more files to open in tabs alongside path
+	text   bool     // dump extracted text to stdout instead of starting the viewer
+	json   bool     // like text, as a JSON array of pages
+	pages  string   // page range for --text, e.g. "3-7"
+	search string   // query for --search; matching paths are printed
+	export string   // page range for --export, e.g. "3-5"; "" means no export
+	out    string   // output directory for --export
+	textTo string   // output file for --export-text
+	width  int      // reflow width for --export-text (0 = raw text)
+	blank  bool     // --include-blank or --all-pages: don't skip pages that look blank
+	page   int      // --page or a path:N suffix: document page to open at (0 = first)
+
+	help    bool // -h/--help: print usage and exit
+	version bool // -v/--version: print the version and exit
+}
+
+// parseArgs reads the command line. Flags that take a value accept it as the
+// next argument or after "=" (--page=42); everything after "--" is a path.
+func parseArgs(args []string) (options, error) {
+	var opts options
+	flagsDone := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if flagsDone || arg == "-" || !strings.HasPrefix(arg, "-") {
+			if opts.path != "" {
+				opts.tabs = append(opts.tabs, arg)
+				continue
+			}
+			opts.path = arg
+			continue
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !strings.HasPrefix(arg, "--") {
+			name, value, hasValue = arg, "", false
+		}
+		switch name {
+		case "--":
+			flagsDone = true
+			continue
+		case "--pages", "--search", "--export", "--out", "--export-text", "--width", "--page":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return opts, fmt.Errorf("%s requires a value", name)
+				}
+				i++
+				value = args[i]
+			}
+		default:
+			if hasValue {
+				return opts, fmt.Errorf("%s doesn't take a value", name)
+			}
+		}
+		switch name {
+		case "-h", "--help":
+			opts.help = true
+		case "-v", "--version":
+			opts.version = true
+		case "--text":
+			opts.text = true
+		case "--json":
+			opts.json = true
+		case "--include-blank", "--all-pages":
+			opts.blank = true
+		case "--pages":
+			opts.pages = value
+		case "--search":
+			opts.search = value
+		case "--export":
+			opts.export = value
+		case "--out":
+			opts.out = value
+		case "--export-text":
+			opts.textTo = value
+		case "--width":
+			w, err := strconv.Atoi(value)
+			if err != nil || w < 0 {
+				return opts, fmt.Errorf("invalid width: %s", value)
+			}
+			opts.width = w
+		case "--page":
+			p, err := strconv.Atoi(value)
+			if err != nil || p < 1 {
+				return opts, fmt.Errorf("invalid page: %s", value)
+			}
+			opts.page = p
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", name)
+		}
+	}
+	// Only the viewer takes several files.
+	if len(opts.tabs) > 0 && (opts.text || opts.json || opts.textTo != "" || opts.export != "" || opts.search != "") {
+		return opts, fmt.Errorf("unexpected argument: %s", opts.tabs[0])
+	}
+	return opts, nil
+}
+
+// splitPageSuffix splits a "file.pdf:42" argument into the file and page.
+// A path that exists as given is never split, so files with a colon and
+// digits in their name still open.
+func splitPageSuffix(arg string) (string, int, bool) {
+	i := strings.LastIndexByte(arg, ':')
+	if i <= 0 {
+		return "", 0, false
+	}
+	page, err := strconv.Atoi(arg[i+1:])
+	if err != nil || page < 1 {
+		return "", 0, false
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return "", 0, false
+	}
+	if _, err := os.Stat(arg[:i]); err != nil {
+		return "", 0, false
+	}
+	return arg[:i], page, true
+}
+
+// parsePageRange parses "N", "N-M", "N-" or "-M" into 1-based inclusive
+// bounds. A zero bound means open-ended.
+func parsePageRange(s string) (int, int, error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+	from, to, isRange := strings.Cut(s, "-")
+	var first, last int
+	var err error
+	if from != "" {
+		if first, err = strconv.Atoi(from); err != nil || first < 1 {
+			return 0, 0, fmt.Errorf("invalid page range: %s", s)
+		}
+	}
+	if !isRange {
+		return first, first, nil
+	}
+	if to != "" {
+		if last, err = strconv.Atoi(to); err != nil || last < 1 {
+			return 0, 0, fmt.Errorf("invalid page range: %s", s)
+		}
+	}
+	if last != 0 && last < first {
+		return 0, 0, fmt.Errorf("invalid page range: %s", s)
+	}
+	return first, last, nil
+}
+
+// dumpText prints the raw extracted text of a document to stdout, as JSON
+// if asJSON is set.
+func dumpText(path, pages string, asJSON bool) error {
+	first, last, err := parsePageRange(pages)
+	if err != nil {
+		return err
+	}
+	v := viewer.NewDocumentViewer(path)
+	if err := v.Open(); err != nil {
+		return err
+	}
+	defer v.Close()
+	if asJSON {
+		return v.DumpJSON(os.Stdout, first, last)
+	}
+	return v.DumpText(os.Stdout, first, last)
+}
+
+// exportText writes the document's text to out, reflowed to width columns
+// if width is set.
+func exportText(path, pages, out string, width int) error {
+	first, last, err := parsePageRange(pages)
+	if err != nil {
+		return err
+	}
+	v := viewer.NewDocumentViewer(path)
+	if err := v.Open(); err != nil {
+		return err
+	}
+	defer v.Close()
+	return v.ExportText(out, first, last, width)
+}
+
+// exportPages renders the pages in the given range to PNG files in dir
+// (default: the current directory) and prints each path written.
+func exportPages(path, pages, dir string) error {
+	first, last, err := parsePageRange(pages)
+	if err != nil {
+		return err
+	}
+	if dir == "" {
+		dir = "."
+	}
+	v := viewer.NewDocumentViewer(path)
+	if err := v.Open(); err != nil {
+		return err
+	}
+	defer v.Close()
+	written, err := v.ExportPages(dir, first, last)
+	for _, p := range written {
+		fmt.Println(p)
+	}
+	return err
+}
+
+// printSearchResults scans the common directories and prints the paths
+// matching query, best match first, one per line.
+func printSearchResults(query string) error {
+	searcher := picker.NewFileSearcher()
+	searcher.Quiet = !term.IsTerminal(int(os.Stdout.Fd()))
+	if err := searcher.ScanDirectories(); err != nil {
+		return fmt.Errorf("error scanning directories: %v", err)
+	}
+	if searcher.Truncated && searcher.Quiet {
+		fmt.Fprintln(os.Stderr, "pdf-cli: scan truncated, results may be incomplete")
+	}
+	for _, result := range searcher.Search(query) {
+		fmt.Println(result.RelativePath)
+	}
+	return nil
+}
+
+// printVersion prints the version, git commit and Go version. Without a
+// stamped commit it falls back to the VCS revision recorded by go build.
+func printVersion() {
+	commit := Commit
+	if commit == "" {
+		commit = "unknown"
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range info.Settings {
+				if s.Key == "vcs.revision" && len(s.Value) >= 12 {
+					commit = s.Value[:12]
+				}
+			}
+		}
+	}
+	fmt.Printf("pdf-cli %s (commit %s, %s)\n", Version, commit, runtime.Version())
+}
+
 func printHelp() {
 	help := `pdf-cli - Terminal-based document viewer
 
 USAGE:
     pdf-cli [OPTIONS] [PATH]
+    pdf-cli [OPTIONS] FILE FILE...
 
 ARGUMENTS:
     [PATH]    File or directory to open (default: current directory)
               - If a directory, opens file picker with fuzzy search
               - If a file, opens it directly
+              - If -, reads a PDF, EPUB or DOCX from stdin
+              Several files open together, one per tab
 
 OPTIONS:
     -h, --help       Show this help message
-    -v, --version    Show version
+    -v, --version    Show version, git commit and Go version
+    --text           Print the document's text to stdout and exit
+    --json           Print the document's pages to stdout as JSON and exit
+    --pages N-M      Limit --text, --json or --export-text to document pages N through M
+    --export-text F  Write the document's text to file F and exit
+    --width N        Reflow --export-text output to N columns (default: raw text)
+    --search QUERY   Print files matching QUERY (from common directories) and exit
+    --export N-M     Save document pages N through M as PNG files and exit
+    --out DIR        Output directory for --export (default: current directory)
+    --include-blank  Show pages that look blank instead of skipping them
+    --all-pages      Same as --include-blank: every page, as paginated
+    --page N         Open the file at document page N (also PATH:N)
+
+    Flags that take a value also accept --flag=value. Put -- before a path
+    that starts with a dash.
 
 SUPPORTED FORMATS:
-    PDF, EPUB, DOCX, HTML
+    PDF, EPUB, DOCX, HTML, TXT, Markdown, CBZ
+    MOBI and AZW3, converted to EPUB with Calibre's ebook-convert
 
 KEYBOARD SHORTCUTS:
-    Navigation:
-        j, Space, Down, Right    Next page
-        k, Up, Left              Previous page
-        g                        Go to specific page
-        c                        Show chapter list (Table of Contents)
-        >                        Next chapter
-        <                        Previous chapter
-        b                        Back to file picker
-
-    Search:
-        /                        Search in document
-        n                        Next search result
-        N                        Previous search result
-
-    Display:
-        t                        Toggle view mode (auto/text/image)
-        f                        Cycle fit modes (height/width/auto)
-        i                        Toggle dark mode (smart invert, preserves hue)
-        D                        Toggle dark mode (simple invert)
-        +, =                     Zoom in
-        -                        Zoom out
-        r                        Refresh display (re-detect cell size)
-        d                        Show debug info
-
-    Other:
-        h                        Show help
-        q                        Quit
-
-EXAMPLES:
+`
+	help += viewer.KeyHelp()
+	help += `EXAMPLES:
     pdf-cli                    Search current directory
     pdf-cli ~/Documents        Search specific directory
     pdf-cli paper.pdf          Open file directly
+    pdf-cli paper.pdf:42       Open file at page 42
+    pdf-cli --text paper.pdf --pages 3-7 | grep lemma
+    pdf-cli --export 3-5 --out figures/ paper.pdf
+    pdf-cli --export-text book.txt --width 80 book.epub
+    pdf-cli --search "annual report" | fzf
 
 For LaTeX workflows, the viewer auto-reloads when the file changes.
 `
@@ -209,8 +617,10 @@ For LaTeX workflows, the viewer auto-reloads when the file changes.
 // runWithBroadSearch scans common directories and opens a file picker.
 // Returns true if the user wants to go back to the main menu.
 func runWithBroadSearch() bool {
+	notice := ""
 	for {
-		filePath, err := selectFileWithPickerBroadSearch()
+		filePath, err := selectFileWithPickerBroadSearch(notice)
+		notice = ""
 		if err != nil {
 			return true // go back to menu
 		}
@@ -220,8 +630,9 @@ func runWithBroadSearch() bool {
 
 		v := viewer.NewDocumentViewer(filePath)
 		if err := v.Open(); err != nil {
-			fmt.Printf("Error opening file: %v\n", err)
-			return false
+			v.Close()
+			notice = fmt.Sprintf("Can't open %s: %v", filepath.Base(filePath), err)
+			continue
 		}
 
 		wantBack := v.Run()
@@ -235,8 +646,10 @@ func runWithBroadSearch() bool {
 // runWithDirectoryPicker scans the given directory and opens a file picker.
 // Returns true if the user wants to go back to the main menu.
 func runWithDirectoryPicker(dir string) bool {
+	notice := ""
 	for {
-		filePath, err := selectFileWithPickerInDir(dir)
+		filePath, err := selectFileWithPickerInDir(dir, notice)
+		notice = ""
 		if err != nil {
 			fmt.Printf("\n  %v\n  Press any key to go back...\n", err)
 			buf := make([]byte, 1)
@@ -249,9 +662,46 @@ func runWithDirectoryPicker(dir string) bool {
 
 		v := viewer.NewDocumentViewer(filePath)
 		if err := v.Open(); err != nil {
-			fmt.Printf("Error opening file: %v\n", err)
+			v.Close()
+			notice = fmt.Sprintf("Can't open %s: %v", filepath.Base(filePath), err)
+			continue
+		}
+
+		wantBack := v.Run()
+		if !wantBack {
 			return false
 		}
+	}
+}
+
+// runWithRecentFiles lists recently opened files, most recent first, in the
+// file picker. Returns true if the user wants to go back to the main menu.
+func runWithRecentFiles() bool {
+	notice := ""
+	for {
+		recent := config.RecentFiles()
+		if len(recent) == 0 {
+			fmt.Printf("\n  No recently opened files yet\n  Press any key to go back...\n")
+			buf := make([]byte, 1)
+			os.Stdin.Read(buf)
+			return true
+		}
+		searcher := picker.NewFileSearcher()
+		searcher.SetFiles(recent)
+		p := picker.NewFilePicker(searcher)
+		p.ShowMessage(notice)
+		notice = ""
+		filePath, err := p.Run()
+		if err != nil || filePath == "" {
+			return true
+		}
+
+		v := viewer.NewDocumentViewer(filePath)
+		if err := v.Open(); err != nil {
+			v.Close()
+			notice = fmt.Sprintf("Can't open %s: %v", filepath.Base(filePath), err)
+			continue
+		}
 
 		wantBack := v.Run()
 		if !wantBack {
@@ -260,7 +710,9 @@ func runWithDirectoryPicker(dir string) bool {
 	}
 }
 
-func selectFileWithPickerInDir(dir string) (string, error) {
+// selectFileWithPickerInDir lists the files under dir in the picker, showing
+// notice, if any, until the first key press.
+func selectFileWithPickerInDir(dir, notice string) (string, error) {
 	searcher := picker.NewFileSearcher()
 	if err := searcher.ScanDirectory(dir); err != nil {
 		return "", fmt.Errorf("error scanning directory: %v", err)
@@ -270,18 +722,24 @@ func selectFileWithPickerInDir(dir string) (string, error) {
 		return "", fmt.Errorf("no supported files found in %s", dir)
 	}
 	p := picker.NewFilePicker(searcher)
+	p.ShowMessage(notice)
 	return p.Run()
 }
 
-func selectFileWithPickerBroadSearch() (string, error) {
+// selectFileWithPickerBroadSearch lists the files in the common directories
+// in the picker, showing notice, if any, until the first key press.
+func selectFileWithPickerBroadSearch(notice string) (string, error) {
 	searcher := picker.NewFileSearcher()
-	if err := searcher.ScanDirectories(); err != nil {
-		return "", fmt.Errorf("error scanning directories: %v", err)
+	if !searcher.LoadIndex() {
+		if err := searcher.ScanDirectories(); err != nil {
+			return "", fmt.Errorf("error scanning directories: %v", err)
+		}
 	}
 	allFiles := searcher.GetAllFiles()
 	if len(allFiles) == 0 {
-		return "", fmt.Errorf("no PDF or EPUB files found in common directories")
+		return "", fmt.Errorf("no PDF, EPUB or DOCX files found in common directories")
 	}
 	p := picker.NewFilePicker(searcher)
+	p.ShowMessage(notice)
 	return p.Run()
 }
@@ -0,0 +1,72 @@
+package battery
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Status is a battery's charge level.
+type Status struct {
+	Percent  int
+	Charging bool
+}
+
+// Read returns the state of the first battery: from /sys/class/power_supply
+// on Linux and pmset on macOS. ok is false on machines without a battery and
+// on other platforms.
+func Read() (s Status, ok bool) {
+	switch runtime.GOOS {
+	case "linux":
+		return readSysfs("/sys/class/power_supply")
+	case "darwin":
+		return readPmset()
+	}
+	return Status{}, false
+}
+
+func readSysfs(dir string) (Status, bool) {
+	supplies, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for _, supply := range supplies {
+		if readFile(filepath.Join(supply, "type")) != "Battery" {
+			continue
+		}
+		pct, err := strconv.Atoi(readFile(filepath.Join(supply, "capacity")))
+		if err != nil {
+			continue
+		}
+		status := readFile(filepath.Join(supply, "status"))
+		return Status{Percent: pct, Charging: status == "Charging" || status == "Full"}, true
+	}
+	return Status{}, false
+}
+
+func readFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// pmsetBattery matches the battery line of "pmset -g batt", e.g.
+// " -InternalBattery-0 (id=...)	87%; charging; 1:02 remaining present: true".
+var pmsetBattery = regexp.MustCompile(`(\d+)%;\s*([\w ]+);`)
+
+func readPmset() (Status, bool) {
+	out, err := exec.Command("pmset", "-g", "batt").Output()
+	if err != nil {
+		return Status{}, false
+	}
+	m := pmsetBattery.FindStringSubmatch(string(out))
+	if m == nil {
+		return Status{}, false
+	}
+	pct, _ := strconv.Atoi(m[1])
+	state := strings.TrimSpace(m[2])
+	return Status{Percent: pct, Charging: state == "charging" || state == "charged"}, true
+}
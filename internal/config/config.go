@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // DocConfig holds per-document settings that persist.
@@ -20,6 +21,44 @@ type DocConfig struct {
 	CropBottom    float64 `json:"crop_bottom"`
 	CropLeft      float64 `json:"crop_left"`
 	CropRight     float64 `json:"crop_right"`
+	MaxDPI        float64 `json:"max_dpi"`
+	Bookmarks     []int   `json:"bookmarks,omitempty"`
+	TextMargin    int     `json:"text_margin"`
+	LineSpacing   int     `json:"line_spacing"`
+	Columns       bool    `json:"columns"`
+	Tables        bool    `json:"tables"`
+	KeepHyphens   bool    `json:"keep_hyphens"`
+	ReflowMode    string  `json:"reflow_mode"`
+	Brightness    float64 `json:"brightness"`
+	Contrast      float64 `json:"contrast"`
+	Grayscale     bool    `json:"grayscale"`
+	Thumbnails    bool    `json:"thumbnails"`
+	Ruler         bool    `json:"ruler"`
+}
+
+// Settings holds global (not per-document) preferences, read from
+// config.json in the config directory.
+type Settings struct {
+	ScanDirs        []string          `json:"scan_dirs"`        // extra directories for the broad file search
+	MaxDepth        int               `json:"max_depth"`        // directory recursion limit for the broad search
+	ReplaceDefaults bool              `json:"replace_defaults"` // scan only ScanDirs, not the built-in list
+	Ignore          []string          `json:"ignore"`           // directory name or path globs to skip while scanning
+	ScanTimeout     float64           `json:"scan_timeout"`     // seconds before a scan gives up and shows what it found; 0 for no limit
+	Theme           string            `json:"theme"`            // color theme name (see package theme)
+	Mouse           bool              `json:"mouse"`            // enable mouse wheel scrolling
+	KeyProfile      string            `json:"key_profile"`      // preset key layout under Keys: "" for the defaults or "less"
+	Keys            map[string]string `json:"keys"`             // viewer action -> key overrides, e.g. "next_page": "n"
+	CellWidth       float64           `json:"cell_width"`       // terminal cell width in pixels, overriding detection
+	CellHeight      float64           `json:"cell_height"`      // terminal cell height in pixels, overriding detection
+	IncludeBlank    bool              `json:"include_blank"`    // show pages that look blank instead of skipping them
+	BlankThreshold  float64           `json:"blank_threshold"`  // share of a page that must differ from its background to count as content
+	Clock           bool              `json:"clock"`            // show the time and battery level at the right of the status bar
+	LiveStatus      bool              `json:"live_status"`      // redraw the status bar every second, not just on each key
+	MaxTextWidth    int               `json:"max_text_width"`   // cap text page lines at this many columns, centered; 0 for the full width
+	HighlightColor  string            `json:"highlight_color"`  // search match color as "#rrggbb"; "" for yellow
+	SelectedColor   string            `json:"selected_color"`   // picker selection background as "#rrggbb"; "" for reverse video
+	AutoScroll      float64           `json:"autoscroll"`       // seconds between auto-scroll steps (1-600)
+	StatusFormat    string            `json:"status_format"`    // status bar layout with {tokens}; "" for the default
 }
 
 // Dir returns the directory used to store per-document config files.
@@ -37,13 +76,20 @@ func Path(absPath string) string {
 	return filepath.Join(Dir(), fmt.Sprintf("%x.json", hash))
 }
 
-// Load loads persisted settings for a document, returning defaults if not found.
-func Load(absPath string) DocConfig {
-	cfg := DocConfig{
+// Defaults returns the settings of a document viewed for the first time.
+func Defaults() DocConfig {
+	return DocConfig{
 		FitMode:       "height",
 		ScaleFactor:   1.0,
 		HTMLPageWidth: 1000,
+		TextMargin:    2,
+		LineSpacing:   1,
 	}
+}
+
+// Load loads persisted settings for a document, returning defaults if not found.
+func Load(absPath string) DocConfig {
+	cfg := Defaults()
 
 	data, err := os.ReadFile(Path(absPath))
 	if err != nil {
@@ -58,6 +104,21 @@ func Load(absPath string) DocConfig {
 	if cfg.HTMLPageWidth < 200 || cfg.HTMLPageWidth > 3000 {
 		cfg.HTMLPageWidth = 1000
 	}
+	if cfg.MaxDPI != 0 && (cfg.MaxDPI < 72 || cfg.MaxDPI > 400) {
+		cfg.MaxDPI = 0
+	}
+	if cfg.TextMargin < 0 || cfg.TextMargin > 20 {
+		cfg.TextMargin = 2
+	}
+	if cfg.LineSpacing < 1 || cfg.LineSpacing > 2 {
+		cfg.LineSpacing = 1
+	}
+	if cfg.Brightness < -0.5 || cfg.Brightness > 0.5 {
+		cfg.Brightness = 0
+	}
+	if cfg.Contrast < -0.5 || cfg.Contrast > 0.8 {
+		cfg.Contrast = 0
+	}
 
 	return cfg
 }
@@ -76,3 +137,199 @@ func Save(absPath string, cfg DocConfig) {
 
 	_ = os.WriteFile(Path(absPath), data, 0o644)
 }
+
+// SettingsPath returns the path of the global settings file.
+func SettingsPath() string {
+	return filepath.Join(Dir(), "config.json")
+}
+
+// LoadSettings loads the global settings, returning defaults if the file is
+// missing or invalid.
+func LoadSettings() Settings {
+	cfg := Settings{
+		MaxDepth:    5,
+		ScanTimeout: 30,
+		AutoScroll:  20,
+	}
+
+	data, err := os.ReadFile(SettingsPath())
+	if err != nil {
+		return cfg
+	}
+
+	_ = json.Unmarshal(data, &cfg)
+
+	if cfg.MaxDepth <= 0 {
+		cfg.MaxDepth = 5
+	}
+	if cfg.CellWidth <= 0 || cfg.CellHeight <= 0 {
+		cfg.CellWidth, cfg.CellHeight = 0, 0
+	}
+	if cfg.ScanTimeout < 0 {
+		cfg.ScanTimeout = 0
+	}
+	if cfg.MaxTextWidth < 0 {
+		cfg.MaxTextWidth = 0
+	}
+	if cfg.AutoScroll < 1 || cfg.AutoScroll > 600 {
+		cfg.AutoScroll = 20
+	}
+
+	return cfg
+}
+
+// SaveSetting stores a single key in the global settings file, leaving the
+// user's other keys as they were.
+func SaveSetting(key string, value any) error {
+	settings := map[string]json.RawMessage{}
+	if data, err := os.ReadFile(SettingsPath()); err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("%s: %v", SettingsPath(), err)
+		}
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	settings[key] = raw
+
+	if err := os.MkdirAll(Dir(), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(SettingsPath(), data, 0o644)
+}
+
+// maxRecent is how many recently opened files are remembered.
+const maxRecent = 20
+
+// RecentPath returns the path of the recently opened files list.
+func RecentPath() string {
+	return filepath.Join(Dir(), "recent.json")
+}
+
+// RecentFiles returns the absolute paths of recently opened files, most
+// recent first. Files that no longer exist are dropped from the list.
+func RecentFiles() []string {
+	var paths []string
+	data, err := os.ReadFile(RecentPath())
+	if err != nil {
+		return nil
+	}
+	_ = json.Unmarshal(data, &paths)
+
+	existing := paths[:0:0]
+	for _, p := range paths {
+		if _, err := os.Stat(p); err == nil {
+			existing = append(existing, p)
+		}
+	}
+	if len(existing) != len(paths) {
+		saveRecent(existing)
+	}
+	return existing
+}
+
+// AddRecent moves absPath to the front of the recent files list.
+func AddRecent(absPath string) {
+	paths := []string{absPath}
+	for _, p := range RecentFiles() {
+		if p != absPath && len(paths) < maxRecent {
+			paths = append(paths, p)
+		}
+	}
+	saveRecent(paths)
+}
+
+func saveRecent(paths []string) {
+	if err := os.MkdirAll(Dir(), 0o755); err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(paths, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(RecentPath(), data, 0o644)
+}
+
+// maxQueries is how many file picker searches are remembered.
+const maxQueries = 50
+
+// QueryHistoryPath returns the path of the file picker's search history.
+func QueryHistoryPath() string {
+	return filepath.Join(Dir(), "queries.json")
+}
+
+// QueryHistory returns the file picker searches that ended in a file being
+// opened, most recent first.
+func QueryHistory() []string {
+	var queries []string
+	data, err := os.ReadFile(QueryHistoryPath())
+	if err != nil {
+		return nil
+	}
+	_ = json.Unmarshal(data, &queries)
+	return queries
+}
+
+// AddQuery moves query to the front of the search history.
+func AddQuery(query string) {
+	queries := []string{query}
+	for _, q := range QueryHistory() {
+		if q != query && len(queries) < maxQueries {
+			queries = append(queries, q)
+		}
+	}
+	if err := os.MkdirAll(Dir(), 0o755); err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(queries, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(QueryHistoryPath(), data, 0o644)
+}
+
+// IndexPath returns the path of the file index, the documents found by the
+// last scan of the common directories.
+func IndexPath() string {
+	return filepath.Join(Dir(), "index.json")
+}
+
+// IndexEntry is a document in the file index, with the size and
+// modification time it had when scanned.
+type IndexEntry struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+}
+
+// IndexedFiles returns the entries in the file index, or nil if there is
+// none yet.
+func IndexedFiles() []IndexEntry {
+	var entries []IndexEntry
+	data, err := os.ReadFile(IndexPath())
+	if err != nil {
+		return nil
+	}
+	if json.Unmarshal(data, &entries) != nil {
+		return nil // an index from an older version; the next scan replaces it
+	}
+	return entries
+}
+
+// SaveIndex replaces the file index with entries.
+func SaveIndex(entries []IndexEntry) {
+	if err := os.MkdirAll(Dir(), 0o755); err != nil {
+		return
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(IndexPath(), data, 0o644)
+}
@@ -1,10 +1,12 @@
 package imgutil
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
 	"math"
+	"strings"
 )
 
 // CropImage trims fractions of each edge from an image.
@@ -84,6 +86,47 @@ func SimpleInvert(src image.Image) image.Image {
 	return dst
 }
 
+// AdjustTone shifts brightness and stretches contrast around mid gray, both
+// from -1 to 1 with 0 leaving the image as it is, and optionally drops the
+// color. It returns src itself when there is nothing to change.
+func AdjustTone(src image.Image, brightness, contrast float64, gray bool) image.Image {
+	if brightness == 0 && contrast == 0 && !gray {
+		return src
+	}
+	// Positive contrast steepens the curve (1/(1-c)), negative flattens it
+	// (1+c), so equal steps feel alike both ways.
+	slope := 1 + contrast
+	if contrast > 0 {
+		slope = 1 / (1 - min(contrast, 0.95))
+	}
+	var curve [256]uint8
+	for i := range curve {
+		v := (float64(i)/255-0.5)*slope + 0.5 + brightness
+		curve[i] = uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+	}
+
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := src.At(x, y).RGBA()
+			r, g, b = r>>8, g>>8, b>>8
+			if gray {
+				// Rec. 601 luma.
+				r = (299*r + 587*g + 114*b) / 1000
+				g, b = r, r
+			}
+			dst.Set(x, y, color.RGBA{
+				R: curve[r],
+				G: curve[g],
+				B: curve[b],
+				A: uint8(a >> 8),
+			})
+		}
+	}
+	return dst
+}
+
 // RGBToHSL converts RGB values (0-1 range) to HSL.
 func RGBToHSL(r, g, b float64) (h, s, l float64) {
 	max := math.Max(r, math.Max(g, b))
@@ -155,3 +198,58 @@ func HueToRGB(p, q, t float64) float64 {
 	}
 	return p
 }
+
+// HalfBlocks renders an image as rows of Unicode upper half-blocks (▀) using
+// 24-bit ANSI colors: each character cell shows two vertically stacked pixels,
+// the top one as foreground and the bottom one as background.
+// The image is box-sampled down to cols x (rows*2) pixels.
+func HalfBlocks(img image.Image, cols, rows int) []string {
+	if cols <= 0 || rows <= 0 {
+		return nil
+	}
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return nil
+	}
+
+	sample := func(cx, py int) (uint8, uint8, uint8) {
+		x0 := b.Min.X + cx*b.Dx()/cols
+		x1 := b.Min.X + (cx+1)*b.Dx()/cols
+		y0 := b.Min.Y + py*b.Dy()/(rows*2)
+		y1 := b.Min.Y + (py+1)*b.Dy()/(rows*2)
+		if x1 <= x0 {
+			x1 = x0 + 1
+		}
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		var rs, gs, bs, n uint64
+		for y := y0; y < y1 && y < b.Max.Y; y++ {
+			for x := x0; x < x1 && x < b.Max.X; x++ {
+				r, g, bl, _ := img.At(x, y).RGBA()
+				rs += uint64(r >> 8)
+				gs += uint64(g >> 8)
+				bs += uint64(bl >> 8)
+				n++
+			}
+		}
+		if n == 0 {
+			return 0, 0, 0
+		}
+		return uint8(rs / n), uint8(gs / n), uint8(bs / n)
+	}
+
+	lines := make([]string, rows)
+	var sb strings.Builder
+	for row := 0; row < rows; row++ {
+		sb.Reset()
+		for col := 0; col < cols; col++ {
+			tr, tg, tb := sample(col, row*2)
+			br, bg, bb := sample(col, row*2+1)
+			fmt.Fprintf(&sb, "\033[38;2;%d;%d;%dm\033[48;2;%d;%d;%dm▀", tr, tg, tb, br, bg, bb)
+		}
+		sb.WriteString("\033[0m")
+		lines[row] = sb.String()
+	}
+	return lines
+}
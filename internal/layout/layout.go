@@ -13,6 +13,10 @@ extern void fz_layout_document(void *ctx, void *doc, float w, float h, float em)
 typedef struct { int chapter; int page; } fz_location;
 extern fz_location fz_resolve_link(void *ctx, void *doc, const char *uri, float *xp, float *yp);
 extern int fz_page_number_from_location(void *ctx, void *doc, fz_location loc);
+
+// fz_authenticate_password unlocks an encrypted document. Returns 0 if the
+// password is wrong.
+extern int fz_authenticate_password(void *ctx, void *doc, const char *password);
 */
 import "C"
 
@@ -50,3 +54,17 @@ func ResolveLink(doc *fitz.Document, uri string) int {
 	pageNum := int(C.fz_page_number_from_location(ctx, docPtr, loc))
 	return pageNum
 }
+
+// AuthenticatePassword unlocks an encrypted document opened by go-fitz,
+// which reports fitz.ErrNeedsPassword but has no way to supply one.
+// Returns false if the password is wrong.
+func AuthenticatePassword(doc *fitz.Document, password string) bool {
+	v := reflect.ValueOf(doc).Elem()
+	ctx := unsafe.Pointer(v.Field(0).Pointer())
+	docPtr := unsafe.Pointer(v.Field(2).Pointer())
+
+	cpw := C.CString(password)
+	defer C.free(unsafe.Pointer(cpw))
+
+	return C.fz_authenticate_password(ctx, docPtr, cpw) != 0
+}
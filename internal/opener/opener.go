@@ -0,0 +1,43 @@
+package opener
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+)
+
+// ErrUnavailable is returned when the platform has no known opener.
+var ErrUnavailable = errors.New("no opener available")
+
+// Command builds the OS command that opens target (a URL or file path) with
+// its default application: open on macOS, start on Windows, xdg-open elsewhere.
+func Command(target string) (*exec.Cmd, error) {
+	var name string
+	var args []string
+	switch runtime.GOOS {
+	case "darwin":
+		name = "open"
+	case "windows":
+		name, args = "cmd", []string{"/c", "start", ""}
+	default:
+		name = "xdg-open"
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, ErrUnavailable
+	}
+	return exec.Command(path, append(args, target)...), nil
+}
+
+// Open launches the default application for target without waiting for it.
+func Open(target string) error {
+	cmd, err := Command(target)
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
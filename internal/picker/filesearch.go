@@ -1,119 +1,292 @@
 package picker
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/mattn/go-runewidth"
 	"github.com/sahilm/fuzzy"
+
+	"pdf-cli/internal/config"
+	"pdf-cli/internal/theme"
 )
 
+// scanWorkers bounds how many top-level directories are walked at once.
+const scanWorkers = 4
+
 // FileResult represents a file found by the searcher.
 type FileResult struct {
 	Path         string
 	RelativePath string
 	Score        int
 	Matches      []int
+	Size         int64
+	ModTime      time.Time
 }
 
 // FileSearcher scans for and searches supported document files.
 type FileSearcher struct {
-	files []string
+	files    []string
+	stats    map[string]os.FileInfo // file info gathered while scanning, by path
+	settings config.Settings
+	Quiet    bool // suppress scanning progress output
+
+	// Truncated is set when the last scan ran out of time (see scan_timeout)
+	// and the file list holds only what was found until then.
+	Truncated bool
+
+	rescan    func() (scanResult, error) // repeats the scan that made the list; nil for a fixed list
+	refreshed chan scanResult            // delivers a background scan for Refreshed to swap in
+}
+
+// scanResult is what a scan found: the files, their info, and whether the
+// scan ran out of time.
+type scanResult struct {
+	files     []string
+	stats     map[string]os.FileInfo
+	truncated bool
 }
 
 // NewFileSearcher creates a new FileSearcher.
 func NewFileSearcher() *FileSearcher {
 	return &FileSearcher{
-		files: []string{},
+		files:    []string{},
+		stats:    map[string]os.FileInfo{},
+		settings: config.LoadSettings(),
 	}
 }
 
-// ScanDirectories scans common directories for PDF/EPUB/DOCX files.
+// ScanDirectories scans common directories, plus any configured scan_dirs,
+// for PDF/EPUB/DOCX files, and saves what it finds as the file index.
 func (fs *FileSearcher) ScanDirectories() error {
-	homeDir, err := os.UserHomeDir()
+	if !fs.Quiet {
+		fmt.Println("Scanning for PDF, EPUB and DOCX files...")
+	}
+	fs.rescan = fs.scanCommon
+	res, err := fs.rescan()
 	if err != nil {
 		return err
 	}
+	fs.apply(res)
 
-	searchDirs := []string{
-		filepath.Join(homeDir, "Documents"),
-		filepath.Join(homeDir, "Downloads"),
-		filepath.Join(homeDir, "Desktop"),
-		filepath.Join(homeDir, "Books"),
-		filepath.Join(homeDir, "Projects"),
-		".",
-		"/usr/share/doc",
-		filepath.Join(homeDir, ".local/share/books"),
+	if !fs.Quiet {
+		if fs.Truncated {
+			fmt.Printf("(scan truncated, %d files)\n\n", len(fs.files))
+		} else {
+			fmt.Printf("Found %d files\n\n", len(fs.files))
+		}
 	}
+	return nil
+}
 
-	const maxDepth = 5
+// LoadIndex fills the file list from the index saved by the last scan of the
+// common directories, and starts a new scan in the background to bring it up
+// to date. Files aren't checked at startup, where a slow mount would hold up
+// the picker; the rescan drops those that have gone since. It reports false
+// if there is no index yet, in which case ScanDirectories has to be waited
+// for.
+func (fs *FileSearcher) LoadIndex() bool {
+	entries := config.IndexedFiles()
+	if len(entries) == 0 {
+		return false
+	}
+	fs.files = make([]string, 0, len(entries))
+	fs.stats = make(map[string]os.FileInfo, len(entries))
+	for _, e := range entries {
+		fs.files = append(fs.files, e.Path)
+		fs.stats[e.Path] = indexedInfo{e}
+	}
+	fs.sortByRecency()
+	fs.rescan = fs.scanCommon
+	fs.Rescan()
+	return true
+}
 
-	fmt.Println("Scanning for PDF and EPUB files...")
+// indexedInfo is the os.FileInfo of a file in the index, as it was when
+// scanned.
+type indexedInfo struct{ e config.IndexEntry }
+
+func (i indexedInfo) Name() string       { return filepath.Base(i.e.Path) }
+func (i indexedInfo) Size() int64        { return i.e.Size }
+func (i indexedInfo) Mode() os.FileMode  { return 0o644 }
+func (i indexedInfo) ModTime() time.Time { return i.e.ModTime }
+func (i indexedInfo) IsDir() bool        { return false }
+func (i indexedInfo) Sys() any           { return nil }
+
+// Rescan repeats the scan that made the file list in the background, for
+// Refreshed to swap in once it is done. It reports false if the list isn't
+// from a scan, such as the recent files.
+func (fs *FileSearcher) Rescan() bool {
+	if fs.rescan == nil {
+		return false
+	}
+	if fs.refreshed == nil {
+		fs.refreshed = make(chan scanResult, 1)
+		go func() {
+			if res, err := fs.rescan(); err == nil {
+				fs.refreshed <- res
+			}
+		}()
+	}
+	return true
+}
 
-	uniqueFiles := make(map[string]bool)
+// Refreshing reports whether a background scan is still running.
+func (fs *FileSearcher) Refreshing() bool {
+	return fs.refreshed != nil
+}
 
-	for _, dir := range searchDirs {
-		if _, err := os.Stat(dir); os.IsNotExist(err) {
-			continue
-		}
+// Refreshed swaps in the files found by the background scan, if it has
+// finished, and reports whether it did.
+func (fs *FileSearcher) Refreshed() bool {
+	select {
+	case res := <-fs.refreshed:
+		fs.refreshed = nil
+		fs.apply(res)
+		return true
+	default:
+		return false
+	}
+}
 
-		absDir, _ := filepath.Abs(dir)
+// apply makes res the file list, newest first.
+func (fs *FileSearcher) apply(res scanResult) {
+	fs.files, fs.stats, fs.Truncated = res.files, res.stats, res.truncated
+	fs.sortByRecency()
+}
 
-		filepath.Walk(absDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil
-			}
+// scanCommon walks the common directories and scan_dirs and saves what it
+// finds as the index, so that's done even if a file is picked before a
+// background scan finishes. A scan that ran out of time isn't saved, since
+// it would drop the files it didn't reach, and neither are files found only
+// in the current directory, which depends on where pdf-cli was started. It
+// only reads fs's settings, so it can run in the background.
+func (fs *FileSearcher) scanCommon() (scanResult, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return scanResult{}, err
+	}
 
-			if strings.HasPrefix(filepath.Base(path), ".") {
-				if info.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
+	var searchDirs []string
+	if !fs.settings.ReplaceDefaults {
+		searchDirs = []string{
+			filepath.Join(homeDir, "Documents"),
+			filepath.Join(homeDir, "Downloads"),
+			filepath.Join(homeDir, "Desktop"),
+			filepath.Join(homeDir, "Books"),
+			filepath.Join(homeDir, "Projects"),
+			".",
+			"/usr/share/doc",
+			filepath.Join(homeDir, ".local/share/books"),
+		}
+	}
+	for _, dir := range fs.settings.ScanDirs {
+		if dir == "~" || strings.HasPrefix(dir, "~/") {
+			dir = filepath.Join(homeDir, dir[1:])
+		}
+		searchDirs = append(searchDirs, dir)
+	}
 
-			if info.IsDir() {
-				// Enforce max depth
-				rel, _ := filepath.Rel(absDir, path)
-				depth := strings.Count(rel, string(filepath.Separator))
-				if depth >= maxDepth {
-					return filepath.SkipDir
+	maxDepth := fs.settings.MaxDepth
+
+	found := newFileSet()
+	truncated := fs.withDeadline(func(ctx context.Context) {
+		// Walk the top-level directories concurrently; slow mounts no longer
+		// hold up the rest of the scan.
+		dirs := make(chan string)
+		var wg sync.WaitGroup
+		for i := 0; i < scanWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for dir := range dirs {
+					fs.walkDir(ctx, dir, maxDepth, found.add)
 				}
-				if info.Name() == "node_modules" || info.Name() == "vendor" || info.Name() == ".git" {
-					return filepath.SkipDir
-				}
-				return nil
-			}
+			}()
+		}
 
-			ext := strings.ToLower(filepath.Ext(path))
-			if ext == ".pdf" || ext == ".epub" || ext == ".docx" {
-				uniqueFiles[path] = true
+	feed:
+		for _, dir := range searchDirs {
+			absDir, _ := filepath.Abs(dir)
+			select {
+			case dirs <- absDir:
+			case <-ctx.Done():
+				break feed
 			}
+		}
+		close(dirs)
+		wg.Wait()
+	})
 
-			return nil
-		})
+	files, stats := found.list()
+	if !truncated {
+		config.SaveIndex(indexEntries(files, stats, searchDirs))
 	}
+	return scanResult{files, stats, truncated}, nil
+}
 
-	fs.files = make([]string, 0, len(uniqueFiles))
-	for file := range uniqueFiles {
-		fs.files = append(fs.files, file)
+// indexEntries returns the index entries for files found in searchDirs,
+// leaving out those only under the current directory.
+func indexEntries(files []string, stats map[string]os.FileInfo, searchDirs []string) []config.IndexEntry {
+	var roots []string
+	for _, dir := range searchDirs {
+		if dir != "." {
+			abs, _ := filepath.Abs(dir)
+			roots = append(roots, abs)
+		}
 	}
+	entries := make([]config.IndexEntry, 0, len(files))
+	for _, path := range files {
+		if !slices.ContainsFunc(roots, func(root string) bool { return isUnder(path, root) }) {
+			continue
+		}
+		info := stats[path]
+		entries = append(entries, config.IndexEntry{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return entries
+}
 
-	fmt.Printf("Found %d files\n\n", len(fs.files))
-	return nil
+// isUnder reports whether path is inside dir.
+func isUnder(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
 
-// ScanDirectory scans a single directory for supported document files.
-func (fs *FileSearcher) ScanDirectory(dir string) error {
-	absDir, err := filepath.Abs(dir)
-	if err != nil {
-		return err
+// withDeadline runs scan until it finishes or the scan timeout passes, and
+// reports whether it was cut short. scan should stop once ctx is done, but a
+// walk blocked on an unresponsive mount can't be interrupted, so it is left
+// to finish in the background; results must be collected in a fileSet,
+// whose list is a snapshot.
+func (fs *FileSearcher) withDeadline(scan func(ctx context.Context)) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	if fs.settings.ScanTimeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(fs.settings.ScanTimeout*float64(time.Second)))
 	}
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scan(ctx)
+	}()
+	select {
+	case <-done:
+		return ctx.Err() != nil
+	case <-ctx.Done():
+		return true
+	}
+}
 
-	var files []string
-
-	err = filepath.Walk(absDir, func(path string, info os.FileInfo, err error) error {
+// walkDir walks absDir up to maxDepth levels deep and calls add for every
+// PDF/EPUB/DOCX file found.
+func (fs *FileSearcher) walkDir(ctx context.Context, absDir string, maxDepth int, add func(path string, info os.FileInfo)) {
+	walk(ctx, absDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
 		}
@@ -125,31 +298,261 @@ func (fs *FileSearcher) ScanDirectory(dir string) error {
 			return nil
 		}
 
+		if info.IsDir() {
+			// Enforce max depth
+			rel, _ := filepath.Rel(absDir, path)
+			depth := strings.Count(rel, string(filepath.Separator))
+			if depth >= maxDepth {
+				return filepath.SkipDir
+			}
+			if info.Name() == "node_modules" || info.Name() == "vendor" || info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if path != absDir && fs.isIgnored(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		ext := strings.ToLower(filepath.Ext(path))
-		if ext == ".pdf" || ext == ".epub" || ext == ".docx" || ext == ".html" || ext == ".htm" {
-			files = append(files, path)
+		if ext == ".pdf" || ext == ".epub" || ext == ".docx" || ext == ".cbz" || ext == ".mobi" || ext == ".azw3" {
+			add(path, info)
 		}
 
 		return nil
 	})
+}
+
+// walk is filepath.Walk, except that it follows symlinks to directories and
+// files and gives fn the target's info. Each directory is entered once, by
+// its resolved path, so a link back up the tree doesn't loop. Linked
+// directories are walked after the rest of the tree, so that a directory
+// reachable both ways is listed under its own path rather than the link's.
+// The walk stops early, without an error, once ctx is done.
+func walk(ctx context.Context, root string, fn filepath.WalkFunc) error {
+	info, err := os.Stat(root)
 	if err != nil {
+		return fn(root, nil, err)
+	}
+	w := &walker{ctx: ctx, fn: fn, visited: map[string]bool{}}
+	err = w.entry(root, info)
+	for len(w.links) > 0 && err == nil {
+		link := w.links[0]
+		w.links = w.links[1:]
+		err = w.entry(link.path, link.info)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+type walker struct {
+	ctx     context.Context
+	fn      filepath.WalkFunc
+	visited map[string]bool // resolved directories entered
+	links   []linkedDir     // symlinked directories still to walk
+}
+
+type linkedDir struct {
+	path string
+	info os.FileInfo // the target's
+}
+
+func (w *walker) entry(path string, info os.FileInfo) error {
+	if w.ctx.Err() != nil {
+		return filepath.SkipAll
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Stat(path)
+		if err != nil {
+			return w.fn(path, info, err) // a dangling link
+		}
+		if target.IsDir() {
+			w.links = append(w.links, linkedDir{path, target})
+			return nil
+		}
+		info = target
+	}
+	if err := w.fn(path, info, nil); err != nil {
+		if err == filepath.SkipDir && info.IsDir() {
+			return nil
+		}
 		return err
 	}
+	if !info.IsDir() {
+		return nil
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil || w.visited[real] {
+		return nil
+	}
+	w.visited[real] = true
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if err := w.fn(path, info, err); err != filepath.SkipDir {
+			return err
+		}
+		return nil
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		info, err := e.Info()
+		if err == nil {
+			err = w.entry(child, info)
+		} else {
+			err = w.fn(child, nil, err)
+		}
+		if err == filepath.SkipDir {
+			return nil // a file asked to skip the rest of this directory
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileSet collects scanned files keyed by their resolved path, so that a
+// document reached through a symlink and also directly is listed once. It is
+// safe for concurrent use.
+type fileSet struct {
+	mu       sync.Mutex
+	stats    map[string]os.FileInfo
+	byTarget map[string]string // resolved path -> path listed
+}
+
+func newFileSet() *fileSet {
+	return &fileSet{stats: map[string]os.FileInfo{}, byTarget: map[string]string{}}
+}
+
+// add records the file at path. Of several paths to one file it keeps the
+// one without symlinks, or else the shortest, whichever was found first.
+func (s *fileSet) add(path string, info os.FileInfo) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		target = path
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if prev, ok := s.byTarget[target]; ok {
+		if prev == target || (path != target && len(prev) <= len(path)) {
+			return
+		}
+		delete(s.stats, prev)
+	}
+	s.byTarget[target] = path
+	s.stats[path] = info
+}
 
-	fs.files = files
+// list returns the paths collected so far, sorted, and their info.
+func (s *fileSet) list() ([]string, map[string]os.FileInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files := make([]string, 0, len(s.stats))
+	stats := make(map[string]os.FileInfo, len(s.stats))
+	for path, info := range s.stats {
+		files = append(files, path)
+		stats[path] = info
+	}
+	sort.Strings(files)
+	return files, stats
+}
+
+// isIgnored reports whether a directory matches one of the configured ignore
+// globs, either by its name (e.g. "Backup*") or its full path.
+func (fs *FileSearcher) isIgnored(dir string) bool {
+	name := filepath.Base(dir)
+	for _, pattern := range fs.settings.Ignore {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, dir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanDirectory scans a single directory for supported document files.
+func (fs *FileSearcher) ScanDirectory(dir string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	fs.rescan = func() (scanResult, error) { return fs.scanDir(absDir), nil }
+	fs.apply(fs.scanDir(absDir))
 	return nil
 }
 
-// Search performs a fuzzy search on the file list.
+// scanDir walks absDir for supported files, to any depth.
+func (fs *FileSearcher) scanDir(absDir string) scanResult {
+	found := newFileSet()
+	truncated := fs.withDeadline(func(ctx context.Context) {
+		walk(ctx, absDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			if strings.HasPrefix(filepath.Base(path), ".") {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if info.IsDir() && path != absDir && fs.isIgnored(path) {
+				return filepath.SkipDir
+			}
+
+			ext := strings.ToLower(filepath.Ext(path))
+			if ext == ".pdf" || ext == ".epub" || ext == ".docx" || ext == ".html" || ext == ".htm" || ext == ".txt" || ext == ".md" || ext == ".cbz" || ext == ".mobi" || ext == ".azw3" {
+				found.add(path, info)
+			}
+
+			return nil
+		})
+	})
+
+	files, stats := found.list()
+	return scanResult{files, stats, truncated}
+}
+
+// SetFiles replaces the file list with paths, kept in the given order, for
+// picking from a list made elsewhere such as the recent files.
+func (fs *FileSearcher) SetFiles(paths []string) {
+	fs.rescan = nil
+	fs.files = append([]string(nil), paths...)
+	fs.stats = make(map[string]os.FileInfo, len(paths))
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil {
+			fs.stats[p] = info
+		}
+	}
+}
+
+// Remove drops path from the file list, once it has been deleted.
+func (fs *FileSearcher) Remove(path string) {
+	fs.files = slices.DeleteFunc(fs.files, func(f string) bool { return f == path })
+	delete(fs.stats, path)
+}
+
+// sortByRecency orders the file list newest first, which is the order shown
+// before anything is typed.
+func (fs *FileSearcher) sortByRecency() {
+	sort.SliceStable(fs.files, func(i, j int) bool {
+		return fs.stats[fs.files[i]].ModTime().After(fs.stats[fs.files[j]].ModTime())
+	})
+}
+
+// Search performs a fuzzy search on the file list, matching each word of
+// query separately (see matchWords). An empty query returns
+// every file in list order: most recently modified first after a scan.
 func (fs *FileSearcher) Search(query string) []FileResult {
-	if query == "" {
+	if strings.TrimSpace(query) == "" {
 		results := make([]FileResult, 0, len(fs.files))
 		for _, file := range fs.files {
-			results = append(results, FileResult{
-				Path:         file,
-				RelativePath: fs.getDisplayPath(file),
-				Score:        0,
-			})
+			results = append(results, fs.result(file, fs.getDisplayPath(file)))
 		}
 		return results
 	}
@@ -159,27 +562,76 @@ func (fs *FileSearcher) Search(query string) []FileResult {
 		displayPaths[i] = fs.getDisplayPath(file)
 	}
 
-	matches := fuzzy.Find(query, displayPaths)
-
+	matches := matchWords(query, displayPaths)
 	results := make([]FileResult, 0, len(matches))
-	for _, match := range matches {
-		if match.Index < len(fs.files) {
-			results = append(results, FileResult{
-				Path:         fs.files[match.Index],
-				RelativePath: displayPaths[match.Index],
-				Score:        match.Score,
-				Matches:      match.MatchedIndexes,
-			})
-		}
+	for _, m := range matches {
+		result := fs.result(fs.files[m.index], displayPaths[m.index])
+		result.Score = m.score
+		result.Matches = m.matched
+		results = append(results, result)
 	}
 
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score < results[j].Score
+	// Higher scores are better matches.
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
 	})
 
 	return results
 }
 
+// pathMatch is a path that matched every word of a query.
+type pathMatch struct {
+	index   int   // into the searched paths
+	score   int   // summed over the words
+	matched []int // byte offsets of the matched characters, ascending
+}
+
+// matchWords fuzzy-matches each space-separated word of query on its own and
+// keeps the paths that match all of them, so "acme q3" finds "Acme Q3
+// Report.pdf" the way "acmeq3" does. A path's score is the sum of its words'.
+func matchWords(query string, paths []string) []pathMatch {
+	var found map[int]*pathMatch
+	for _, word := range strings.Fields(query) {
+		next := map[int]*pathMatch{}
+		for _, m := range fuzzy.Find(word, paths) {
+			score := m.Score + basenameBonus(word, paths[m.Index], m.MatchedIndexes)
+			if found == nil {
+				next[m.Index] = &pathMatch{index: m.Index, score: score, matched: m.MatchedIndexes}
+			} else if prev, ok := found[m.Index]; ok {
+				prev.score += score
+				prev.matched = append(prev.matched, m.MatchedIndexes...)
+				next[m.Index] = prev
+			}
+		}
+		found = next
+	}
+	matches := make([]pathMatch, 0, len(found))
+	for _, m := range found {
+		slices.Sort(m.matched)
+		m.matched = slices.Compact(m.matched)
+		matches = append(matches, *m)
+	}
+	// Map order is random; keep the scan order among equal scores.
+	sort.Slice(matches, func(i, j int) bool { return matches[i].index < matches[j].index })
+	return matches
+}
+
+// basenameBonus favours matches that land in the file name over ones spread
+// through the directories, so typing a file name brings that file to the top.
+func basenameBonus(query, path string, matched []int) int {
+	baseStart := strings.LastIndexByte(path, filepath.Separator) + 1
+	bonus := 0
+	for _, idx := range matched {
+		if idx >= baseStart {
+			bonus += 5
+		}
+	}
+	if strings.Contains(strings.ToLower(path[baseStart:]), strings.ToLower(query)) {
+		bonus += 25
+	}
+	return bonus
+}
+
 func (fs *FileSearcher) getDisplayPath(path string) string {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -197,18 +649,47 @@ func (fs *FileSearcher) getDisplayPath(path string) string {
 func (fs *FileSearcher) GetAllFiles() []FileResult {
 	results := make([]FileResult, 0, len(fs.files))
 	for _, file := range fs.files {
-		results = append(results, FileResult{
-			Path:         file,
-			RelativePath: fs.getDisplayPath(file),
-		})
+		results = append(results, fs.result(file, fs.getDisplayPath(file)))
 	}
 	return results
 }
 
+// result builds a FileResult for path, filling in the size and modification
+// time recorded during the scan.
+func (fs *FileSearcher) result(path, displayPath string) FileResult {
+	r := FileResult{Path: path, RelativePath: displayPath}
+	if info, ok := fs.stats[path]; ok {
+		r.Size, r.ModTime = info.Size(), info.ModTime()
+	}
+	return r
+}
+
 // HighlightMatches returns the file path with matched characters highlighted.
 func (fr *FileResult) HighlightMatches() string {
+	return fr.HighlightMatchesWidth(0)
+}
+
+// HighlightMatchesWidth is HighlightMatches with the path cut to at most width
+// columns, ending in "…" when shortened. A width of 0 means no limit.
+func (fr *FileResult) HighlightMatchesWidth(width int) string {
+	return fr.HighlightMatchesStyle(width, theme.Color("\033[1;33m", "\033[1m"), "\033[0m")
+}
+
+// HighlightMatchesStyle is HighlightMatchesWidth with the matched characters
+// set in style; after restores the surrounding style following each match.
+func (fr *FileResult) HighlightMatchesStyle(width int, style, after string) string {
+	path := fr.RelativePath
+	truncated := false
+	if width > 0 && runewidth.StringWidth(path) > width {
+		path = runewidth.Truncate(path, max(width-1, 0), "")
+		truncated = true
+	}
+
 	if len(fr.Matches) == 0 {
-		return fr.RelativePath
+		if truncated {
+			return path + "…"
+		}
+		return path
 	}
 
 	var result strings.Builder
@@ -217,15 +698,18 @@ func (fr *FileResult) HighlightMatches() string {
 		matchSet[idx] = true
 	}
 
-	for i, char := range fr.RelativePath {
+	for i, char := range path {
 		if matchSet[i] {
-			result.WriteString("\033[1;33m")
+			result.WriteString(style)
 			result.WriteRune(char)
-			result.WriteString("\033[0m")
+			result.WriteString(after)
 		} else {
 			result.WriteRune(char)
 		}
 	}
+	if truncated {
+		result.WriteString("…")
+	}
 
 	return result.String()
 }
@@ -0,0 +1,232 @@
+package picker
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sahilm/fuzzy"
+
+	"pdf-cli/internal/config"
+)
+
+// newTestSearcher returns a searcher with the given settings instead of the
+// user's, and keeps the index it saves out of the real config directory.
+func newTestSearcher(t *testing.T, settings config.Settings) *FileSearcher {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if settings.MaxDepth == 0 {
+		settings.MaxDepth = 5
+	}
+	return &FileSearcher{files: []string{}, stats: map[string]os.FileInfo{}, settings: settings, Quiet: true}
+}
+
+// touch creates an empty file at path, and its directories.
+func touch(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// paths returns the paths of results, in order.
+func paths(results []FileResult) []string {
+	var p []string
+	for _, r := range results {
+		p = append(p, r.Path)
+	}
+	return p
+}
+
+func TestScanDirectoryFindsDocx(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, filepath.Join(dir, "report.docx"))
+	touch(t, filepath.Join(dir, "sub", "paper.pdf"))
+	touch(t, filepath.Join(dir, "notes.odt"))
+	touch(t, filepath.Join(dir, ".hidden", "secret.docx"))
+
+	fs := newTestSearcher(t, config.Settings{})
+	if err := fs.ScanDirectory(dir); err != nil {
+		t.Fatal(err)
+	}
+	got := paths(fs.GetAllFiles())
+	slices.Sort(got)
+	want := []string{filepath.Join(dir, "report.docx"), filepath.Join(dir, "sub", "paper.pdf")}
+	if !slices.Equal(got, want) {
+		t.Errorf("GetAllFiles() = %q, want %q", got, want)
+	}
+}
+
+func TestScanDirectoriesScansConfiguredDirs(t *testing.T) {
+	extra := t.TempDir()
+	book := filepath.Join(extra, "shelf", "novel.epub")
+	touch(t, book)
+
+	fs := newTestSearcher(t, config.Settings{ScanDirs: []string{extra}, ReplaceDefaults: true})
+	if err := fs.ScanDirectories(); err != nil {
+		t.Fatal(err)
+	}
+	if got := paths(fs.GetAllFiles()); !slices.Equal(got, []string{book}) {
+		t.Errorf("GetAllFiles() = %q, want %q", got, []string{book})
+	}
+}
+
+func TestScanDirectoriesIndex(t *testing.T) {
+	extra, cwd := t.TempDir(), t.TempDir()
+	book := filepath.Join(extra, "novel.epub")
+	touch(t, book)
+	touch(t, filepath.Join(cwd, "here.pdf"))
+	t.Chdir(cwd)
+
+	fs := newTestSearcher(t, config.Settings{ScanDirs: []string{extra, "."}, ReplaceDefaults: true})
+	if err := fs.ScanDirectories(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(fs.GetAllFiles()); n != 2 {
+		t.Fatalf("found %d files, want 2", n)
+	}
+	var indexed []string
+	for _, e := range config.IndexedFiles() {
+		indexed = append(indexed, e.Path)
+	}
+	if !slices.Equal(indexed, []string{book}) {
+		t.Errorf("index = %q, want %q", indexed, []string{book})
+	}
+}
+
+func TestScanDirectoriesTruncatedKeepsIndex(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, filepath.Join(dir, "a.pdf"))
+
+	fs := newTestSearcher(t, config.Settings{ScanDirs: []string{dir}, ReplaceDefaults: true, ScanTimeout: 1e-9})
+	old := []config.IndexEntry{{Path: "/books/old.pdf", Size: 1}}
+	config.SaveIndex(old)
+	if err := fs.ScanDirectories(); err != nil {
+		t.Fatal(err)
+	}
+	if !fs.Truncated {
+		t.Fatal("scan wasn't truncated")
+	}
+	if got := config.IndexedFiles(); !slices.Equal(got, old) {
+		t.Errorf("index = %v after a truncated scan, want %v", got, old)
+	}
+}
+
+func TestLoadIndexTrustsIndex(t *testing.T) {
+	fs := newTestSearcher(t, config.Settings{ReplaceDefaults: true})
+	older := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+	// Neither file exists; they are listed as indexed until the rescan.
+	config.SaveIndex([]config.IndexEntry{
+		{Path: "/gone/old.pdf", Size: 100, ModTime: older},
+		{Path: "/gone/new.pdf", Size: 200, ModTime: newer},
+	})
+	if !fs.LoadIndex() {
+		t.Fatal("LoadIndex() = false")
+	}
+	got := fs.GetAllFiles()
+	if len(got) != 2 || got[0].Path != "/gone/new.pdf" || got[0].Size != 200 || !got[0].ModTime.Equal(newer) {
+		t.Errorf("GetAllFiles() = %+v, want /gone/new.pdf (200 bytes, %v) first", got, newer)
+	}
+	for !fs.Refreshed() {
+		time.Sleep(time.Millisecond)
+	}
+	if n := len(fs.GetAllFiles()); n != 0 {
+		t.Errorf("found %d files after the rescan, want 0", n)
+	}
+}
+
+func TestIsIgnored(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		dir      string
+		want     bool
+	}{
+		{[]string{"Backup*"}, "/home/me/Backup2023", true},
+		{[]string{"Backup*"}, "/home/me/Documents", false},
+		{[]string{"Backup*"}, "/home/me/Backup2023/Documents", false},
+		{[]string{"/mnt/slow/*"}, "/mnt/slow/archive", true},
+		{[]string{"/mnt/slow/*"}, "/mnt/fast/archive", false},
+		{[]string{"node*", "/mnt/slow/*"}, "/home/me/node_cache", true},
+		{nil, "/home/me/Backup2023", false},
+	}
+	for _, tt := range tests {
+		fs := &FileSearcher{settings: config.Settings{Ignore: tt.patterns}}
+		if got := fs.isIgnored(tt.dir); got != tt.want {
+			t.Errorf("isIgnored(%q) with %q = %v, want %v", tt.dir, tt.patterns, got, tt.want)
+		}
+	}
+}
+
+func TestSearchPrefersBasenameMatch(t *testing.T) {
+	// The same words, once as directories and once as the file name.
+	inDir := "/data/report/notes.pdf"
+	inBase := "/data/notes/report.pdf"
+
+	fs := newTestSearcher(t, config.Settings{})
+	fs.SetFiles([]string{inDir, inBase})
+	got := paths(fs.Search("report"))
+	if want := []string{inBase, inDir}; !slices.Equal(got, want) {
+		t.Errorf("Search(%q) = %q, want %q", "report", got, want)
+	}
+}
+
+func TestMatchWords(t *testing.T) {
+	files := []string{
+		"/books/download.pdf",
+		"/books/Deep Learning.pdf",
+		"/docs/acme report.pdf",
+		"/docs/Acme Q3 Report.pdf",
+		"/docs/acme/q3/notes.pdf",
+	}
+	tests := []struct {
+		query string
+		want  []string // best first
+	}{
+		// Every word must match, in any order and not necessarily next to
+		// each other.
+		{"acme q3", []string{"/docs/Acme Q3 Report.pdf", "/docs/acme/q3/notes.pdf"}},
+		{"q3 acme", []string{"/docs/Acme Q3 Report.pdf", "/docs/acme/q3/notes.pdf"}},
+		{"acme report", []string{"/docs/acme report.pdf", "/docs/Acme Q3 Report.pdf"}},
+		{"acme zzz", nil},
+		// Abbreviations rank as they did with a single fuzzy match.
+		{"dl", []string{"/books/Deep Learning.pdf", "/books/download.pdf"}},
+		{"deep learn", []string{"/books/Deep Learning.pdf"}},
+	}
+	for _, tt := range tests {
+		matches := matchWords(tt.query, files)
+		slices.SortStableFunc(matches, func(a, b pathMatch) int { return b.score - a.score })
+		var got []string
+		for _, m := range matches {
+			got = append(got, files[m.index])
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("matchWords(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestMatchWordsAgainstWholeQuery(t *testing.T) {
+	files := []string{"/docs/Acme Q3 Report.pdf", "/docs/acme/q3/notes.pdf"}
+
+	// Matched as one string, the words had to appear in the typed order with
+	// the space in between, so reordering them or a path separator between
+	// them lost the file.
+	if old := fuzzy.Find("q3 acme", files); len(old) != 0 {
+		t.Fatalf("fuzzy.Find(%q) found %d files; the comparison no longer holds", "q3 acme", len(old))
+	}
+	if old := fuzzy.Find("acme q3", files); len(old) != 1 {
+		t.Fatalf("fuzzy.Find(%q) found %d files; the comparison no longer holds", "acme q3", len(old))
+	}
+	for _, q := range []string{"q3 acme", "acme q3"} {
+		if got := matchWords(q, files); len(got) != 2 {
+			t.Errorf("matchWords(%q) found %d files, want 2", q, len(got))
+		}
+	}
+}
@@ -3,9 +3,20 @@ package picker
 import (
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/mattn/go-runewidth"
 	"golang.org/x/term"
+
+	"pdf-cli/internal/config"
+	"pdf-cli/internal/opener"
+	"pdf-cli/internal/terminal"
+	"pdf-cli/internal/theme"
+	"pdf-cli/internal/viewer"
 )
 
 // FilePicker provides a TUI for selecting files with fuzzy search.
@@ -18,11 +29,29 @@ type FilePicker struct {
 	termHeight    int
 	termWidth     int
 	oldState      *term.State
+	previews      map[string]string // first-page text by path ("" = unavailable)
+	matchStyle    string            // SGR sequence for matched characters
+	selectedStyle string            // SGR sequence for the selected row
+	message       string            // shown in place of the key help until the next key
+	history       []string          // earlier searches, most recent first
+	historyIdx    int               // the search recalled from history; -1 while typing
+	draft         string            // the query typed before recalling earlier ones
 }
 
+// previewDelay is how long the selection must rest before its preview is
+// loaded, so scrolling through the list doesn't open every file.
+const previewDelay = 150 * time.Millisecond
+
+// previewMinWidth is the narrowest terminal that gets a preview pane.
+const previewMinWidth = 90
+
+// resizePoll is how often the picker checks for a resize while it waits for
+// a key.
+const resizePoll = 50 * time.Millisecond
+
 // NewFilePicker creates a new FilePicker with the given searcher.
 func NewFilePicker(searcher *FileSearcher) *FilePicker {
-	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+	width, height := terminal.GetSize()
 	return &FilePicker{
 		searcher:      searcher,
 		query:         "",
@@ -30,9 +59,20 @@ func NewFilePicker(searcher *FileSearcher) *FilePicker {
 		displayOffset: 0,
 		termHeight:    height,
 		termWidth:     width,
+		previews:      map[string]string{},
+		history:       config.QueryHistory(),
+		historyIdx:    -1,
+		matchStyle:    theme.Foreground(searcher.settings.HighlightColor, "\033[1;33m", "\033[1m"),
+		selectedStyle: theme.Background(searcher.settings.SelectedColor, "\033[7m", "\033[7m"),
 	}
 }
 
+// ShowMessage shows msg in place of the key help until the first key press,
+// e.g. to say why the file picked last time couldn't be opened.
+func (fp *FilePicker) ShowMessage(msg string) {
+	fp.message = msg
+}
+
 // Run displays the picker and returns the selected file path.
 func (fp *FilePicker) Run() (string, error) {
 	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
@@ -43,25 +83,81 @@ func (fp *FilePicker) Run() (string, error) {
 	defer term.Restore(int(os.Stdin.Fd()), oldState)
 	fmt.Print("\033[?25l")
 	defer fmt.Print("\033[?25h")
+	if fp.searcher.settings.Mouse {
+		terminal.EnableMouse()
+		defer terminal.DisableMouse()
+	}
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
+	defer signal.Stop(resized)
 	fp.updateResults()
 	for {
 		fp.render()
-		char := fp.readChar()
-		switch char {
-		case 3: // Ctrl+C
+		key, ok := fp.readKey(resized)
+		if !ok {
+			continue
+		}
+		fp.message = ""
+		switch key {
+		case 3, terminal.KeyEscape, 0: // Ctrl+C, Esc or stdin closed
 			return "", fmt.Errorf("cancelled")
-		case 27: // ESC or arrow keys
-			if fp.handleEscapeSequence() {
-				return "", fmt.Errorf("cancelled")
+		case terminal.KeyUp, terminal.KeyWheelUp:
+			if len(fp.results) == 0 && key == terminal.KeyUp {
+				fp.recall(1)
+			} else if fp.selectedIndex > 0 {
+				fp.selectedIndex--
+				fp.ensureSelectedVisible()
+			}
+		case terminal.KeyDown, terminal.KeyWheelDown:
+			if len(fp.results) == 0 && key == terminal.KeyDown {
+				fp.recall(-1)
+			} else if fp.selectedIndex < len(fp.results)-1 {
+				fp.selectedIndex++
+				fp.ensureSelectedVisible()
 			}
 		case 127, 8: // Backspace/Delete
 			if len(fp.query) > 0 {
 				fp.query = fp.query[:len(fp.query)-1]
 				fp.updateResults()
 			}
+		case 16: // Ctrl+P: recall the previous search
+			fp.recall(1)
+		case 14: // Ctrl+N: back towards the query being typed
+			fp.recall(-1)
+		case 21: // Ctrl+U: clear the query
+			fp.query = ""
+			fp.updateResults()
+		case 23: // Ctrl+W: delete the last word
+			q := strings.TrimRight(fp.query, " ")
+			fp.query = q[:strings.LastIndex(q, " ")+1]
+			fp.updateResults()
 		case 13: // Enter
 			if len(fp.results) > 0 && fp.selectedIndex < len(fp.results) {
-				return fp.results[fp.selectedIndex].Path, nil
+				result := fp.results[fp.selectedIndex]
+				if _, err := os.Stat(result.Path); os.IsNotExist(err) {
+					fp.searcher.Remove(result.Path)
+					fp.reloadResults()
+					fp.message = result.RelativePath + " is no longer there"
+					break
+				}
+				if strings.TrimSpace(fp.query) != "" {
+					config.AddQuery(fp.query)
+				}
+				return result.Path, nil
+			}
+		case 15: // Ctrl+O: open the selected file's directory
+			if len(fp.results) > 0 {
+				fp.openDirectory(fp.results[fp.selectedIndex].Path)
+			}
+		case 4: // Ctrl+D: move the selected file to the trash
+			if len(fp.results) > 0 {
+				fp.trash(fp.results[fp.selectedIndex])
+			}
+		case 18: // Ctrl+R: scan again for new files
+			if fp.searcher.Rescan() {
+				fp.previews = map[string]string{}
+			} else {
+				fp.message = "This list isn't from a scan"
 			}
 		case 9: // Tab
 			if len(fp.results) > 0 {
@@ -69,59 +165,153 @@ func (fp *FilePicker) Run() (string, error) {
 				fp.ensureSelectedVisible()
 			}
 		default:
-			if char >= 32 && char < 127 {
-				fp.query += string(char)
+			if key >= 32 && key < 127 {
+				fp.query += string(rune(key))
 				fp.updateResults()
 			}
 		}
 	}
 }
 
-func (fp *FilePicker) handleEscapeSequence() bool {
-	seq := make([]byte, 2)
-	n, _ := os.Stdin.Read(seq)
-	if n == 0 {
-		return true
+// readKey waits for a key press, loading the selected file's preview once
+// the selection has rested for previewDelay. It reports false, with no key,
+// when the picker needs redrawing instead: after a preview loads, the
+// terminal is resized or a background scan updates the list.
+func (fp *FilePicker) readKey(resized <-chan os.Signal) (terminal.Key, bool) {
+	start := time.Now()
+	for {
+		if key, ok := terminal.ReadKeyTimeout(resizePoll); ok {
+			return key, true
+		}
+		select {
+		case <-resized:
+			fp.updateSize()
+			return 0, false
+		default:
+		}
+		if fp.searcher.Refreshed() {
+			fp.reloadResults()
+			return 0, false
+		}
+		if fp.needsPreview() && time.Since(start) >= previewDelay {
+			fp.loadPreview()
+			return 0, false
+		}
 	}
-	if n >= 2 && seq[0] == '[' {
-		switch seq[1] {
-		case 'A': // Up arrow
-			if fp.selectedIndex > 0 {
-				fp.selectedIndex--
-				fp.ensureSelectedVisible()
-			}
-		case 'B': // Down arrow
-			if fp.selectedIndex < len(fp.results)-1 {
-				fp.selectedIndex++
-				fp.ensureSelectedVisible()
+}
+
+// updateSize picks up the terminal's new size, scrolling the list so the
+// selection stays in view and a taller window isn't left half empty.
+func (fp *FilePicker) updateSize() {
+	fp.termWidth, fp.termHeight = terminal.GetSize()
+	fp.displayOffset = max(min(fp.displayOffset, len(fp.results)-fp.visibleLines()), 0)
+	fp.ensureSelectedVisible()
+}
+
+// visibleLines is the number of results the list has room for below the
+// header.
+func (fp *FilePicker) visibleLines() int {
+	return max(fp.termHeight-9, 1)
+}
+
+// openDirectory shows the directory holding path in the OS file manager,
+// leaving the picker open.
+func (fp *FilePicker) openDirectory(path string) {
+	dir := filepath.Dir(path)
+	if err := opener.Open(dir); err != nil {
+		fp.message = fmt.Sprintf("Can't open %s: %v", dir, err)
+		return
+	}
+	fp.message = "Opened " + dir
+}
+
+// trash moves the selected file to the trash once the user confirms, or
+// deletes it outright, after a sterner warning, when there is no trash to
+// move it to. The file then leaves the list.
+func (fp *FilePicker) trash(result FileResult) {
+	cmd := trashCommand(result.Path)
+	if cmd == nil {
+		if !fp.confirm("\033[1;31mNo trash available: delete " + result.RelativePath + " permanently? This can't be undone.\033[0m") {
+			return
+		}
+		if err := os.Remove(result.Path); err != nil {
+			fp.message = fmt.Sprintf("Can't delete %s: %v", result.RelativePath, err)
+			return
+		}
+		fp.message = "Deleted " + result.RelativePath
+	} else {
+		if !fp.confirm("Move " + result.RelativePath + " to the trash?") {
+			return
+		}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				err = fmt.Errorf("%s", msg)
 			}
+			fp.message = fmt.Sprintf("Can't move %s to the trash: %v", result.RelativePath, err)
+			return
 		}
+		fp.message = "Moved " + result.RelativePath + " to the trash"
 	}
 
-	return false
+	fp.searcher.Remove(result.Path)
+	fp.reloadResults()
 }
 
-func (fp *FilePicker) readChar() byte {
-	buf := make([]byte, 1)
-	n, _ := os.Stdin.Read(buf)
-	if n > 0 {
-		return buf[0]
+// confirm asks question on the bottom row and reports whether it was
+// answered with y. Any other key, Enter included, means no.
+func (fp *FilePicker) confirm(question string) bool {
+	fmt.Printf("\033[%d;1H\033[2K  %s (y/N) ", fp.termHeight, question)
+	key := terminal.ReadKey()
+	return key == 'y' || key == 'Y'
+}
+
+// reloadResults runs the query again after the file list has changed,
+// keeping the selection on the same file if it is still listed.
+func (fp *FilePicker) reloadResults() {
+	selected := ""
+	if fp.selectedIndex < len(fp.results) {
+		selected = fp.results[fp.selectedIndex].Path
+	}
+	fp.results = fp.searcher.Search(fp.query)
+	fp.selectedIndex = max(min(fp.selectedIndex, len(fp.results)-1), 0)
+	for i, r := range fp.results {
+		if r.Path == selected {
+			fp.selectedIndex = i
+			break
+		}
+	}
+	fp.displayOffset = max(min(fp.displayOffset, len(fp.results)-fp.visibleLines()), 0)
+	fp.ensureSelectedVisible()
+}
+
+// recall steps through earlier searches like a shell's history: dir 1 goes
+// to an older one, -1 back towards the query that was being typed.
+func (fp *FilePicker) recall(dir int) {
+	i := fp.historyIdx + dir
+	if i < -1 || i >= len(fp.history) {
+		return
+	}
+	if fp.historyIdx == -1 {
+		fp.draft = fp.query
 	}
-	return 0
+	if i == -1 {
+		fp.query = fp.draft
+	} else {
+		fp.query = fp.history[i]
+	}
+	fp.updateResults()
+	fp.historyIdx = i
 }
 
 func (fp *FilePicker) updateResults() {
+	fp.historyIdx = -1
 	fp.results = fp.searcher.Search(fp.query)
 	fp.selectedIndex = 0
 	fp.displayOffset = 0
 }
 
 func (fp *FilePicker) ensureSelectedVisible() {
-	visibleLines := fp.termHeight - 9
-	if visibleLines < 1 {
-		visibleLines = 1
-	}
-
+	visibleLines := fp.visibleLines()
 	if fp.selectedIndex < fp.displayOffset {
 		fp.displayOffset = fp.selectedIndex
 	} else if fp.selectedIndex >= fp.displayOffset+visibleLines {
@@ -130,45 +320,212 @@ func (fp *FilePicker) ensureSelectedVisible() {
 }
 
 func (fp *FilePicker) render() {
+	t := theme.Get(fp.searcher.settings.Theme)
 	fmt.Print("\033[2J\033[H")
-	fmt.Print("\033[1;36m╔═══════════════════════════════════════════════════════════════╗\033[0m\r\n")
-	fmt.Print("\033[1;36m║\033[0m              \033[1;37mPDF/EPUB File Selector\033[0m                     \033[1;36m║\033[0m\r\n")
-	fmt.Print("\033[1;36m╚═══════════════════════════════════════════════════════════════╝\033[0m\r\n")
-	fmt.Printf("\033[1;32m>\033[0m %s\033[0m\r\n", fp.query)
+	fmt.Print(t.Accent + "╔═══════════════════════════════════════════════════════════════╗\033[0m\r\n")
+	fmt.Print(t.Accent + "║\033[0m              " + t.Title + "PDF/EPUB File Selector\033[0m                     " + t.Accent + "║\033[0m\r\n")
+	fmt.Print(t.Accent + "╚═══════════════════════════════════════════════════════════════╝\033[0m\r\n")
+	fmt.Printf("%s>\033[0m %s\033[0m\r\n", t.Prompt, fp.query)
 	fmt.Print(strings.Repeat("─", fp.termWidth))
 	fmt.Print("\r\n")
 
-	visibleLines := fp.termHeight - 9
-	if visibleLines < 1 {
-		visibleLines = 1
-	}
-
+	visibleLines := fp.visibleLines()
 	if len(fp.results) == 0 {
 		fmt.Print("\033[2m  No files found\033[0m\r\n")
 		fmt.Print("\r\n")
 		fmt.Print("\033[2m  Try a different search query or press Ctrl+C to exit\033[0m\r\n")
 	} else {
-		fmt.Printf("\033[2m  Found %d file(s)\033[0m\r\n\r\n", len(fp.results))
+		note := ""
+		if fp.searcher.Refreshing() {
+			note = " (scanning…)"
+		} else if fp.searcher.Truncated {
+			note = " (scan truncated)"
+		}
+		fmt.Printf("\033[2m  Found %d file(s)%s\033[0m\r\n\r\n", len(fp.results), note)
 		endIndex := fp.displayOffset + visibleLines
 		if endIndex > len(fp.results) {
 			endIndex = len(fp.results)
 		}
 		for i := fp.displayOffset; i < endIndex; i++ {
 			result := fp.results[i]
+			// Size and age sit right-aligned; the path gets what is left.
+			meta := fmt.Sprintf("%8s  %8s", formatSize(result.Size), relativeTime(result.ModTime))
+			pathWidth := fp.listWidth() - 2 - len(meta) - 2
+			padding := max(pathWidth-runewidth.StringWidth(runewidth.Truncate(result.RelativePath, max(pathWidth, 10), "…")), 0)
+			// A match ends with a reset, which must restore the selection.
+			after := "\033[0m"
+			if i == fp.selectedIndex {
+				after += fp.selectedStyle
+			}
+			path := result.HighlightMatchesStyle(max(pathWidth, 10), fp.matchStyle, after)
 			if i == fp.selectedIndex {
-				fmt.Print("\033[7m► ")
-				fmt.Print(result.HighlightMatches())
-				fmt.Print("\033[0m\r\n")
+				fmt.Print(fp.selectedStyle + "► ")
+				fmt.Print(path + strings.Repeat(" ", padding))
+				fmt.Print("  " + meta + "\033[0m\r\n")
 			} else {
 				fmt.Print("  ")
-				fmt.Print(result.HighlightMatches())
-				fmt.Print("\r\n")
+				fmt.Print(path + strings.Repeat(" ", padding))
+				fmt.Print("  \033[2m" + meta + "\033[0m\r\n")
 			}
 		}
 		if len(fp.results) > visibleLines {
 			fmt.Printf("\r\n\033[2m  [%d-%d of %d]\033[0m", fp.displayOffset+1, endIndex, len(fp.results))
 		}
 	}
-	fmt.Print("\r\n\r\n")
-	fmt.Print("\033[2m  ↑/↓: Navigate  Enter: Select  Tab: Next  Backspace: Clear  Esc/Ctrl+C: Exit\033[0m")
+	fp.renderPreview()
+	fmt.Printf("\033[%d;1H", fp.termHeight)
+	if fp.message != "" {
+		fmt.Print("  " + fp.message)
+		return
+	}
+	fmt.Print("\033[2m  ↑/↓: Navigate  Enter: Select  Tab: Next  Ctrl+W/U: Delete word/all  Ctrl+P/N: History  Ctrl+O: Open folder  Ctrl+D: Trash  Ctrl+R: Rescan  Esc/Ctrl+C: Exit\033[0m")
+}
+
+// showPreview reports whether the terminal is wide enough for a preview pane.
+func (fp *FilePicker) showPreview() bool {
+	return fp.termWidth >= previewMinWidth
+}
+
+// listWidth is the width available to the file list, which gives up its
+// right third to the preview pane when one is shown.
+func (fp *FilePicker) listWidth() int {
+	if fp.showPreview() {
+		return fp.termWidth * 2 / 3
+	}
+	return fp.termWidth
+}
+
+// needsPreview reports whether the selected file's preview still has to be
+// loaded.
+func (fp *FilePicker) needsPreview() bool {
+	if !fp.showPreview() || fp.selectedIndex >= len(fp.results) {
+		return false
+	}
+	_, ok := fp.previews[fp.results[fp.selectedIndex].Path]
+	return !ok
+}
+
+// loadPreview extracts the start of the selected file's text and caches it.
+// The document is closed straight away; only the text is kept.
+func (fp *FilePicker) loadPreview() {
+	path := fp.results[fp.selectedIndex].Path
+
+	restoreStderr := terminal.SilenceStderr()
+	defer restoreStderr()
+
+	doc, err := viewer.OpenBackend(path)
+	if err != nil {
+		fp.previews[path] = ""
+		return
+	}
+	defer doc.Close()
+
+	// Skip blank cover pages.
+	text := ""
+	for n := 0; n < min(doc.NumPage(), 3) && strings.TrimSpace(text) == ""; n++ {
+		text, _ = doc.Text(n)
+	}
+	words := strings.Fields(text)
+	text = strings.Join(words, " ")
+	if r := []rune(text); len(r) > 600 {
+		text = string(r[:600]) + "…"
+	}
+	fp.previews[path] = text
+}
+
+// renderPreview draws the selected file's preview in the right-hand pane.
+func (fp *FilePicker) renderPreview() {
+	if !fp.showPreview() || len(fp.results) == 0 {
+		return
+	}
+	col := fp.listWidth() + 2
+	width := fp.termWidth - col - 1
+	top, bottom := 6, fp.termHeight-2
+
+	text, loaded := fp.previews[fp.results[fp.selectedIndex].Path]
+	var lines []string
+	switch {
+	case !loaded:
+		lines = []string{"\033[2mloading…\033[0m"}
+	case text == "":
+		lines = []string{"\033[2mpreview unavailable\033[0m"}
+	default:
+		lines = wrapWords(text, width)
+	}
+
+	for row := top; row <= bottom; row++ {
+		fmt.Printf("\033[%d;%dH\033[2m│\033[0m ", row, col-1)
+		if i := row - top; i < len(lines) {
+			line := lines[i]
+			if loaded {
+				line = runewidth.Truncate(line, width, "")
+			}
+			fmt.Print(line)
+		}
+	}
+}
+
+// wrapWords wraps space-separated text to lines at most width columns wide.
+func wrapWords(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		switch {
+		case line == "":
+			line = word
+		case runewidth.StringWidth(line)+1+runewidth.StringWidth(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+		// Text written without spaces, such as Chinese or Japanese, is
+		// broken wherever the width runs out.
+		for runewidth.StringWidth(line) > width {
+			head := runewidth.Truncate(line, width, "")
+			if head == "" {
+				break
+			}
+			lines = append(lines, head)
+			line = line[len(head):]
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// formatSize renders a byte count as B, KB, MB or GB.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// relativeTime renders how long ago t was, e.g. "5m ago" or "3d ago".
+func relativeTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/24/30))
+	}
+	return fmt.Sprintf("%dy ago", int(d.Hours()/24/365))
 }
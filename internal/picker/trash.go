@@ -0,0 +1,23 @@
+package picker
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// trashCommand builds the command that moves path to the desktop trash:
+// Finder via osascript on macOS, gio or trash-cli elsewhere. It returns nil
+// when no trash tool is installed.
+func trashCommand(path string) *exec.Cmd {
+	if runtime.GOOS == "darwin" {
+		quoted := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(path)
+		return exec.Command("osascript", "-e", `tell application "Finder" to delete POSIX file "`+quoted+`"`)
+	}
+	for _, tool := range [][]string{{"gio", "trash"}, {"trash-put"}, {"trash"}} {
+		if _, err := exec.LookPath(tool[0]); err == nil {
+			return exec.Command(tool[0], append(tool[1:], path)...)
+		}
+	}
+	return nil
+}
@@ -1,13 +1,16 @@
 package terminal
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 	"unsafe"
 
+	"golang.org/x/sys/unix"
 	"golang.org/x/term"
 )
 
@@ -25,8 +28,18 @@ func GetSize() (int, int) {
 	return 80, 24
 }
 
-// DetectType returns the terminal type string.
+// InTmux reports whether the program runs inside tmux.
+func InTmux() bool {
+	return os.Getenv("TMUX") != "" || os.Getenv("TERM_PROGRAM") == "tmux"
+}
+
+// DetectType returns the terminal type string. Inside tmux it is "tmux"
+// whatever the outer terminal is, since tmux sits between us and it and
+// variables like KITTY_WINDOW_ID leak in from the outer terminal.
 func DetectType() string {
+	if InTmux() {
+		return "tmux"
+	}
 	if termProgram := os.Getenv("TERM_PROGRAM"); termProgram != "" {
 		switch termProgram {
 		case "WezTerm":
@@ -99,6 +112,23 @@ func GetKittyCellSize() (float64, float64) {
 	if DetectType() != "kitty" {
 		return 0, 0
 	}
+	return QueryCellSize()
+}
+
+// cellQueryTimeout is how long QueryCellSize waits for the terminal's reply.
+const cellQueryTimeout = 100 * time.Millisecond
+
+// cellQueryUnanswered records that the terminal ignored the cell size query,
+// so later calls don't wait for it again.
+var cellQueryUnanswered bool
+
+// QueryCellSize asks the terminal for its cell size in pixels with the
+// "ESC [ 16 t" escape, which it answers with "ESC [ 6 ; height ; width t".
+// Terminals that don't support the query stay silent; it returns zeros then.
+func QueryCellSize() (float64, float64) {
+	if cellQueryUnanswered {
+		return 0, 0
+	}
 
 	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
 	if err != nil {
@@ -114,44 +144,65 @@ func GetKittyCellSize() (float64, float64) {
 	defer term.Restore(fd, oldState)
 
 	tty.WriteString("\x1b[16t")
-	tty.Sync()
 
-	resultChan := make(chan string, 1)
-	go func() {
+	// Poll rather than block in Read so that a terminal that never answers
+	// doesn't leave a reader behind to swallow the next key press.
+	var response []byte
+	deadline := time.Now().Add(cellQueryTimeout)
+	for !bytes.HasSuffix(response, []byte("t")) {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		if n, err := unix.Poll(fds, int(remaining.Milliseconds())+1); err != nil || n == 0 {
+			break
+		}
 		buf := make([]byte, 32)
-		n, _ := tty.Read(buf)
-		if n > 0 {
-			resultChan <- string(buf[:n])
-		} else {
-			resultChan <- ""
+		n, err := tty.Read(buf)
+		if err != nil || n == 0 {
+			break
 		}
-	}()
+		response = append(response, buf[:n]...)
+	}
+	if len(response) == 0 {
+		cellQueryUnanswered = true
+		return 0, 0
+	}
 
-	select {
-	case response := <-resultChan:
-		if response == "" {
-			return 0, 0
-		}
+	// Skip anything typed ahead of the reply.
+	if i := bytes.Index(response, []byte("\x1b[6;")); i >= 0 {
 		var cellHeight, cellWidth int
-		if _, err := fmt.Sscanf(response, "\x1b[6;%d;%dt", &cellHeight, &cellWidth); err == nil {
+		if _, err := fmt.Sscanf(string(response[i:]), "\x1b[6;%d;%dt", &cellHeight, &cellWidth); err == nil {
 			if cellWidth > 0 && cellHeight > 0 {
 				return float64(cellWidth), float64(cellHeight)
 			}
 		}
-	case <-time.After(100 * time.Millisecond):
 	}
-
 	return 0, 0
 }
 
-// DetectCellSize detects cell dimensions in pixels.
-func DetectCellSize() (float64, float64) {
-	if cellSize := os.Getenv("DOCVIEWER_CELL_SIZE"); cellSize != "" {
+// EnvCellSize returns the cell size set in PDFCLI_CELL (or the older
+// DOCVIEWER_CELL_SIZE) as WIDTHxHEIGHT pixels, or zeros if neither is set.
+func EnvCellSize() (float64, float64) {
+	for _, name := range []string{"PDFCLI_CELL", "DOCVIEWER_CELL_SIZE"} {
+		cellSize := os.Getenv(name)
+		if cellSize == "" {
+			continue
+		}
 		var w, h float64
 		if _, err := fmt.Sscanf(cellSize, "%fx%f", &w, &h); err == nil && w > 0 && h > 0 {
 			return w, h
 		}
 	}
+	return 0, 0
+}
+
+// DetectCellSize detects cell dimensions in pixels.
+func DetectCellSize() (float64, float64) {
+	if w, h := EnvCellSize(); w > 0 && h > 0 {
+		return w, h
+	}
 
 	if kw, kh := GetKittyCellSize(); kw > 0 && kh > 0 {
 		return kw, kh
@@ -168,6 +219,10 @@ func DetectCellSize() (float64, float64) {
 		}
 	}
 
+	if qw, qh := QueryCellSize(); qw > 0 && qh > 0 {
+		return qw, qh
+	}
+
 	termType := DetectType()
 	switch termType {
 	case "kitty":
@@ -187,6 +242,18 @@ func DetectCellSize() (float64, float64) {
 	}
 }
 
+// UseTTY makes the controlling terminal stdin, for when the real stdin is a
+// pipe carrying the document, so keys can still be read. Everything here
+// goes through os.Stdin, so this is the one place that needs to know.
+func UseTTY() error {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("no terminal to read keys from: %v", err)
+	}
+	os.Stdin = tty
+	return nil
+}
+
 // SetRawMode puts the terminal into raw mode.
 func SetRawMode() (*term.State, error) {
 	return term.MakeRaw(int(os.Stdin.Fd()))
@@ -199,56 +266,210 @@ func RestoreTerminal(old *term.State) {
 	}
 }
 
-// ReadSingleChar reads a single character from stdin, handling escape sequences.
-func ReadSingleChar() byte {
-	buf := make([]byte, 1)
-	n, _ := os.Stdin.Read(buf)
-	if n == 0 {
-		return 0
-	}
-
-	if buf[0] == 27 {
-		b := make([]byte, 1)
-		n, _ = os.Stdin.Read(b)
-		if n == 1 && b[0] == '[' {
-			n, _ = os.Stdin.Read(b)
-			if n == 1 {
-				switch b[0] {
-				case 'A':
-					return 'k'
-				case 'B':
-					return 'j'
-				case 'C':
-					return 'j'
-				case 'D':
-					return 'k'
-				case '1':
-					seq := make([]byte, 3)
-					n2 := 0
-					for i := 0; i < 3; i++ {
-						nn, _ := os.Stdin.Read(seq[i : i+1])
-						if nn == 0 {
-							break
-						}
-						n2++
-					}
-					if n2 == 3 && seq[0] == ';' && seq[1] == '2' {
-						switch seq[2] {
-						case 'A':
-							return 'K'
-						case 'B':
-							return 'J'
-						case 'C':
-							return 'J'
-						case 'D':
-							return 'K'
-						}
-					}
-				}
-			}
+// Key is a decoded key press: either a plain byte value or one of the Key
+// constants below for keys that arrive as escape sequences.
+type Key rune
+
+// KeyEscape is a lone ESC press.
+const KeyEscape Key = 27
+
+// Special keys returned by ReadKey. They lie beyond the Unicode range so they
+// can't collide with typed characters.
+const (
+	KeyUp Key = 0x110000 + iota
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyShiftUp
+	KeyShiftDown
+	KeyShiftRight
+	KeyShiftLeft
+	KeyHome
+	KeyEnd
+	KeyPageUp
+	KeyPageDown
+	KeyWheelUp // mouse wheel; only reported after EnableMouse
+	KeyWheelDown
+	KeyBackTab // Shift+Tab
+	KeyUnknown // an escape sequence that isn't mapped to a key
+)
+
+// pending holds bytes read from stdin but not yet decoded, e.g. when several
+// keys arrive in one read. pendingMu guards it, and stdin with it.
+var (
+	pendingMu sync.Mutex
+	pending   []byte
+)
+
+// ReadKey reads one key press from stdin, decoding escape sequences. It is the
+// only place escape sequences are parsed. Returns 0 if stdin is closed.
+func ReadKey() Key {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	return readKey()
+}
+
+// readKey is ReadKey with pendingMu held.
+func readKey() Key {
+	if len(pending) == 0 {
+		// A terminal sends an escape sequence in a single write, so one read
+		// returns the whole sequence and a lone ESC comes back by itself.
+		buf := make([]byte, 64)
+		n, _ := os.Stdin.Read(buf)
+		if n == 0 {
+			return 0
 		}
-		return 27
+		pending = buf[:n]
 	}
 
-	return buf[0]
+	// Over slow links a sequence can be split across reads. Give the rest
+	// a moment to arrive so a bare ESC is still reported promptly.
+	for pending[0] == 27 && !escapeComplete(pending) && waitForInput(escapeTimeout) {
+		buf := make([]byte, 64)
+		n, _ := os.Stdin.Read(buf)
+		if n == 0 {
+			break
+		}
+		pending = append(pending, buf[:n]...)
+	}
+
+	key, size := decodeKey(pending)
+	pending = pending[size:]
+	return key
+}
+
+// EnableMouse turns on SGR mouse reporting so wheel scrolls arrive as
+// KeyWheelUp/KeyWheelDown. Pair with DisableMouse before exiting.
+func EnableMouse() {
+	fmt.Print("\033[?1000h\033[?1006h")
+}
+
+// DisableMouse turns mouse reporting back off.
+func DisableMouse() {
+	fmt.Print("\033[?1006l\033[?1000l")
+}
+
+// ReadKeyTimeout is ReadKey with a deadline. It reports false if no key
+// arrived within timeout.
+func ReadKeyTimeout(timeout time.Duration) (Key, bool) {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	if len(pending) == 0 && !waitForInput(timeout) {
+		return 0, false
+	}
+	return readKey(), true
+}
+
+// SilenceStderr points stderr at /dev/null so library warnings (MuPDF writes
+// them straight to fd 2) don't land on a full-screen UI. The returned func
+// restores it.
+func SilenceStderr() func() {
+	savedStderr, _ := syscall.Dup(2)
+	devNull, _ := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if devNull != nil && savedStderr != -1 {
+		syscall.Dup2(int(devNull.Fd()), 2)
+	}
+	return func() {
+		if savedStderr != -1 {
+			syscall.Dup2(savedStderr, 2)
+			syscall.Close(savedStderr)
+		}
+		if devNull != nil {
+			devNull.Close()
+		}
+	}
+}
+
+// escapeTimeout is how long to wait for the rest of an escape sequence
+// before treating ESC as a key press of its own.
+const escapeTimeout = 50 * time.Millisecond
+
+// escapeComplete reports whether buf, which starts with ESC, holds a whole
+// escape sequence: ESC plus one byte, or a CSI ("ESC [") or SS3 ("ESC O")
+// sequence up to its final byte.
+func escapeComplete(buf []byte) bool {
+	if len(buf) < 2 {
+		return false
+	}
+	if buf[1] != '[' && buf[1] != 'O' {
+		return true
+	}
+	for _, b := range buf[2:] {
+		if b >= 0x40 && b <= 0x7e {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForInput reports whether stdin becomes readable within timeout.
+func waitForInput(timeout time.Duration) bool {
+	fds := []unix.PollFd{{Fd: int32(os.Stdin.Fd()), Events: unix.POLLIN}}
+	n, err := unix.Poll(fds, int(timeout.Milliseconds()))
+	return err == nil && n > 0
+}
+
+// decodeKey decodes the first key in buf and returns it with the number of
+// bytes it used. CSI and SS3 sequences are read up to their final byte, so
+// variable-length sequences like "ESC [ 5 ~" or "ESC [ 1 ; 2 A" are consumed
+// whole; ones that aren't mapped decode as KeyUnknown.
+func decodeKey(buf []byte) (Key, int) {
+	if buf[0] != 27 || len(buf) == 1 {
+		return Key(buf[0]), 1
+	}
+	if buf[1] != '[' && buf[1] != 'O' {
+		return KeyEscape, 1
+	}
+
+	end := 2
+	for end < len(buf) && (buf[end] < 0x40 || buf[end] > 0x7e) {
+		end++
+	}
+	if end == len(buf) {
+		return KeyUnknown, len(buf) // truncated sequence
+	}
+	params, final, size := string(buf[2:end]), buf[end], end+1
+
+	// SGR mouse report: "ESC [ < button ; x ; y M". Only the wheel is used.
+	if strings.HasPrefix(params, "<") && (final == 'M' || final == 'm') {
+		switch strings.SplitN(params[1:], ";", 2)[0] {
+		case "64":
+			return KeyWheelUp, size
+		case "65":
+			return KeyWheelDown, size
+		}
+		return KeyUnknown, size
+	}
+
+	switch final {
+	case 'A', 'B', 'C', 'D':
+		return arrowKey(final, params == "1;2"), size
+	case 'H':
+		return KeyHome, size
+	case 'Z':
+		return KeyBackTab, size
+	case 'F':
+		return KeyEnd, size
+	case '~':
+		switch params {
+		case "1", "7":
+			return KeyHome, size
+		case "4", "8":
+			return KeyEnd, size
+		case "5":
+			return KeyPageUp, size
+		case "6":
+			return KeyPageDown, size
+		}
+	}
+	return KeyUnknown, size
+}
+
+// arrowKey maps the final byte of an arrow sequence (A-D) to its Key. The
+// Key constants are declared in the same A, B, C, D order.
+func arrowKey(final byte, shift bool) Key {
+	if shift {
+		return KeyShiftUp + Key(final-'A')
+	}
+	return KeyUp + Key(final-'A')
 }
@@ -0,0 +1,48 @@
+package theme
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseHex parses a 24-bit color written as "#rrggbb" or "#rgb" (the "#" is
+// optional).
+func ParseHex(spec string) (r, g, b uint8, ok bool) {
+	s := strings.TrimPrefix(strings.TrimSpace(spec), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), true
+}
+
+// Foreground returns the SGR sequence for bold text in the hex color spec.
+// An empty or invalid spec gives fallback instead. Either way plain is
+// returned when colors are off, as with Color.
+func Foreground(spec, fallback, plain string) string {
+	if r, g, b, ok := ParseHex(spec); ok {
+		return Color(fmt.Sprintf("\033[1;38;2;%d;%d;%dm", r, g, b), plain)
+	}
+	return Color(fallback, plain)
+}
+
+// Background is like Foreground but sets the background to spec, with
+// black or white text, whichever reads better on it.
+func Background(spec, fallback, plain string) string {
+	r, g, b, ok := ParseHex(spec)
+	if !ok {
+		return Color(fallback, plain)
+	}
+	text := "97"
+	if 299*int(r)+587*int(g)+114*int(b) > 128*1000 {
+		text = "30"
+	}
+	return Color(fmt.Sprintf("\033[48;2;%d;%d;%d;%sm", r, g, b, text), plain)
+}
@@ -0,0 +1,111 @@
+// Package theme defines the color themes shared by the viewer and the file
+// picker. Colors are raw SGR escape sequences; an empty sequence leaves the
+// terminal's own colors in place.
+//
+// Colors are turned off, following https://no-color.org, when NO_COLOR is
+// set or stdout is not a terminal.
+package theme
+
+import (
+	"os"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// Theme is a named set of colors for the UI.
+type Theme struct {
+	Name   string
+	Text   string // page text foreground and background
+	Status string // viewer status line
+	Accent string // picker borders
+	Title  string // picker title
+	Prompt string // picker query prompt
+}
+
+var themes = []Theme{
+	{
+		Name:   "default",
+		Accent: "\033[1;36m",
+		Title:  "\033[1;37m",
+		Prompt: "\033[1;32m",
+	},
+	{
+		Name:   "dark",
+		Text:   "\033[38;2;220;220;220m\033[48;2;30;30;30m",
+		Status: "\033[38;2;150;150;150m\033[48;2;30;30;30m",
+		Accent: "\033[38;2;120;160;200m",
+		Title:  "\033[1;38;2;220;220;220m",
+		Prompt: "\033[1;38;2;120;200;140m",
+	},
+	{
+		Name:   "light",
+		Text:   "\033[38;2;30;30;30m\033[48;2;250;250;245m",
+		Status: "\033[38;2;90;90;90m\033[48;2;230;230;225m",
+		Accent: "\033[38;2;40;90;160m",
+		Title:  "\033[1;38;2;30;30;30m",
+		Prompt: "\033[1;38;2;30;120;60m",
+	},
+	{
+		Name:   "sepia",
+		Text:   "\033[38;2;230;170;90m\033[48;2;40;30;20m",
+		Status: "\033[38;2;180;130;70m\033[48;2;40;30;20m",
+		Accent: "\033[38;2;200;140;60m",
+		Title:  "\033[1;38;2;240;190;110m",
+		Prompt: "\033[1;38;2;230;170;90m",
+	},
+	{
+		Name:   "contrast",
+		Text:   "\033[1;97m\033[40m",
+		Status: "\033[1;93m\033[40m",
+		Accent: "\033[1;93m",
+		Title:  "\033[1;97m",
+		Prompt: "\033[1;93m",
+	},
+}
+
+var colorEnabled = sync.OnceValue(func() bool {
+	return os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(os.Stdout.Fd()))
+})
+
+// ColorEnabled reports whether color escape sequences should be emitted.
+func ColorEnabled() bool {
+	return colorEnabled()
+}
+
+// Color returns seq when colors are enabled and plain otherwise. plain may
+// use attributes such as bold or reverse video, which NO_COLOR allows, or be
+// empty.
+func Color(seq, plain string) string {
+	if colorEnabled() {
+		return seq
+	}
+	return plain
+}
+
+// Get returns the theme with the given name, or the default theme. With
+// colors disabled every theme is colorless.
+func Get(name string) Theme {
+	for _, t := range themes {
+		if t.Name == name {
+			if !colorEnabled() {
+				return Theme{Name: t.Name}
+			}
+			return t
+		}
+	}
+	if !colorEnabled() {
+		return Theme{Name: themes[0].Name}
+	}
+	return themes[0]
+}
+
+// Next returns the name of the theme after name, wrapping around.
+func Next(name string) string {
+	for i, t := range themes {
+		if t.Name == name {
+			return themes[(i+1)%len(themes)].Name
+		}
+	}
+	return themes[1%len(themes)].Name
+}
@@ -6,6 +6,9 @@ import (
 	"strings"
 
 	"golang.org/x/term"
+
+	"pdf-cli/internal/terminal"
+	"pdf-cli/internal/theme"
 )
 
 // ANSI formatting (uses terminal's own color scheme)
@@ -95,6 +98,7 @@ type MenuItem struct {
 var MainMenuItems = []MenuItem{
 	{Label: "📂  Browse Files", Description: "Search across common directories"},
 	{Label: "📁  Enter Directory", Description: "Open a specific directory path"},
+	{Label: "🕘  Recent Files", Description: "Reopen a recently viewed file"},
 }
 
 // applyLogoStyle styles the logo using bold + terminal default accent color.
@@ -105,7 +109,7 @@ func applyLogoStyle(text string) string {
 		if strings.TrimSpace(line) == "" {
 			result[i] = line
 		} else {
-			result[i] = bold + fgCyan + line + reset
+			result[i] = bold + theme.Color(fgCyan, "") + line + reset
 		}
 	}
 	return strings.Join(result, "\n")
@@ -135,7 +139,7 @@ func RenderMainMenu(selected int) string {
 		if i == selected {
 			// Selected: bold with arrow indicator
 			line := fmt.Sprintf("  %s%s▸ %s%s  %s%s%s",
-				fgBrCyan, bold, item.Label, reset,
+				theme.Color(fgBrCyan, ""), bold, item.Label, reset,
 				dim, item.Description, reset)
 			menuLines = append(menuLines, line)
 		} else {
@@ -207,7 +211,7 @@ func RenderMainMenu(selected int) string {
 
 // MenuResult holds the result of the main menu interaction.
 type MenuResult struct {
-	Selection int    // 0 = Browse, 1 = Enter Directory, -1 = Quit
+	Selection int    // 0 = Browse, 1 = Enter Directory, 2 = Recent Files, -1 = Quit
 	DirPath   string // populated when Selection == 1
 }
 
@@ -228,17 +232,15 @@ func RunMainMenu() MenuResult {
 		fmt.Print(clearScreen)
 		fmt.Print(RenderMainMenu(selected))
 
-		// Read input
-		buf := make([]byte, 3)
-		n, _ := os.Stdin.Read(buf)
-		if n == 0 {
+		key := terminal.ReadKey()
+		if key == 0 {
 			continue
 		}
 
 		switch {
-		case buf[0] == 'q' || buf[0] == 3: // q or Ctrl+C
+		case key == 'q' || key == 3: // q or Ctrl+C
 			return MenuResult{Selection: -1}
-		case buf[0] == 13: // Enter
+		case key == 13: // Enter
 			if selected == 1 {
 				// "Enter Directory" — prompt for path
 				term.Restore(int(os.Stdin.Fd()), oldState)
@@ -253,14 +255,14 @@ func RunMainMenu() MenuResult {
 				return MenuResult{Selection: 1, DirPath: dir}
 			}
 			return MenuResult{Selection: selected}
-		case buf[0] == 'j' || (n >= 3 && buf[0] == 27 && buf[1] == '[' && buf[2] == 'B'): // j or Down
+		case key == 'j' || key == terminal.KeyDown: // j or Down
 			selected = (selected + 1) % len(MainMenuItems)
-		case buf[0] == 'k' || (n >= 3 && buf[0] == 27 && buf[1] == '[' && buf[2] == 'A'): // k or Up
+		case key == 'k' || key == terminal.KeyUp: // k or Up
 			selected--
 			if selected < 0 {
 				selected = len(MainMenuItems) - 1
 			}
-		case buf[0] == 27 && n == 1: // plain ESC
+		case key == terminal.KeyEscape: // plain ESC
 			return MenuResult{Selection: -1}
 		}
 	}
@@ -287,7 +289,7 @@ func promptForDirectory() string {
 	// Draw static parts
 	drawPromptChrome := func() {
 		fmt.Printf("\033[%d;1H\033[K", promptRow)
-		fmt.Printf("%s", centerText(fmt.Sprintf("%s%sEnter directory path:%s ", fgBrCyan, bold, reset), width))
+		fmt.Printf("%s", centerText(fmt.Sprintf("%s%sEnter directory path:%s ", theme.Color(fgBrCyan, ""), bold, reset), width))
 		fmt.Printf("\033[%d;1H\033[K", helpRow)
 		fmt.Printf("%s", centerText(fmt.Sprintf("%sESC cancel • Enter confirm • Tab complete • ~ = home%s", dim, reset), width))
 	}
@@ -483,7 +485,7 @@ func drawCandidateList(candidates []string, highlighted int, startRow, padLeft,
 
 		display := name + "/"
 		if i == highlighted {
-			fmt.Printf("%s%s%-*s%s", fgBrCyan, bold, colWidth, display, reset)
+			fmt.Printf("%s%s%-*s%s", theme.Color(fgBrCyan, ""), bold, colWidth, display, reset)
 		} else {
 			fmt.Printf("%s%-*s%s", dim, colWidth, display, reset)
 		}
@@ -0,0 +1,107 @@
+package viewer
+
+import (
+	"errors"
+	"image"
+	"path/filepath"
+	"strings"
+
+	"pdf-cli/internal/terminal"
+)
+
+// DocumentBackend is a source of pages for the viewer. Page numbers are
+// 0-indexed. Backends without rendered pages return an error from the image
+// methods and Bound; the viewer then treats their pages as text.
+type DocumentBackend interface {
+	NumPage() int
+	Text(n int) (string, error)
+	Image(n int) (image.Image, error)
+	ImageDPI(n int, dpi float64) (image.Image, error)
+	Bound(n int) (image.Rectangle, error)
+	ToC() ([]Chapter, error)
+	Close() error
+}
+
+// metadataSource is implemented by backends that carry document metadata
+// such as title and author.
+type metadataSource interface {
+	Metadata() map[string]string
+}
+
+// imageSource is implemented by backends that can pull out the images
+// embedded in a page, as opposed to rendering the whole page.
+type imageSource interface {
+	EmbeddedImages(n int) ([]embeddedImage, error)
+}
+
+// embeddedImage is an image placed on a page, in its file encoding.
+type embeddedImage struct {
+	format        string // "jpeg", "png"...
+	width, height int    // in pixels; 0 if the data couldn't be decoded
+	data          []byte
+}
+
+// ext is the file extension to save the image with.
+func (img embeddedImage) ext() string {
+	if img.format == "jpeg" {
+		return "jpg"
+	}
+	return img.format
+}
+
+// Link is a hyperlink on a page. Internal links have the 0-indexed target
+// Page; external ones have Page -1 and only a URI.
+type Link struct {
+	URI  string
+	Page int
+}
+
+// linkSource is implemented by backends that expose page hyperlinks.
+type linkSource interface {
+	Links(n int) ([]Link, error)
+}
+
+// layouter is implemented by backends that can reflow HTML to a page size.
+type layouter interface {
+	layout(w, h, em float64)
+}
+
+// OpenBackend opens path with the backend for its file extension. It is for
+// callers outside the viewer, such as the file picker's preview pane.
+func OpenBackend(path string) (DocumentBackend, error) {
+	fileType := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	_, rows := terminal.GetSize()
+	return openBackend(path, fileType, rows-2, nil)
+}
+
+// maxPasswordAttempts is how many passwords are tried on an encrypted
+// document before giving up.
+const maxPasswordAttempts = 3
+
+var (
+	errEncrypted     = errors.New("document is password-protected")
+	errWrongPassword = errors.New("incorrect password")
+	errDRM           = errors.New("this EPUB is DRM-protected and cannot be opened")
+)
+
+// openBackend opens path with the backend for fileType. Plain text is split
+// into pages of linesPerPage lines, comic archives are read directly, and
+// everything else goes through MuPDF, or a text-only PDF reader in builds
+// without cgo.
+//
+// For encrypted documents password is asked for up to maxPasswordAttempts
+// passwords (attempt counts from 1); it returns false to give up. A nil
+// password fails encrypted documents straight away.
+func openBackend(path, fileType string, linesPerPage int, password func(attempt int) (string, bool)) (DocumentBackend, error) {
+	// MuPDF opens DRM'd EPUBs but finds nothing readable in them.
+	if fileType == "epub" && epubDRM(path) {
+		return nil, errDRM
+	}
+	switch fileType {
+	case "txt", "md":
+		return newTextBackend(path, fileType == "md", linesPerPage)
+	case "cbz":
+		return newCBZBackend(path)
+	}
+	return openMuPDF(path, fileType, password)
+}
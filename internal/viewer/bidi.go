@@ -0,0 +1,69 @@
+package viewer
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/bidi"
+)
+
+// rtlScripts are the right-to-left scripts told apart when deciding a
+// paragraph's direction.
+var rtlScripts = []*unicode.RangeTable{unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana, unicode.Nko}
+
+// rtlLines reports, for each of a page's reflowed lines, whether it belongs
+// to a paragraph written mostly in a right-to-left script. Paragraphs are
+// runs of lines between blank ones; their direction is that of most of
+// their letters, so a Latin name in Arabic text doesn't turn a line around.
+func rtlLines(lines []string) []bool {
+	rtl := make([]bool, len(lines))
+	start, right, left := 0, 0, 0
+	for i := 0; i <= len(lines); i++ {
+		if i == len(lines) || strings.TrimSpace(lines[i]) == "" {
+			if right > left {
+				for j := start; j < i; j++ {
+					rtl[j] = true
+				}
+			}
+			start, right, left = i+1, 0, 0
+			continue
+		}
+		for _, r := range lines[i] {
+			switch {
+			case !unicode.IsLetter(r):
+			case unicode.IsOneOf(rtlScripts, r):
+				right++
+			default:
+				left++
+			}
+		}
+	}
+	return rtl
+}
+
+// visualOrder reorders a line of a right-to-left paragraph, kept in logical
+// order in the text, into the left-to-right order a terminal draws it in,
+// following the Unicode bidirectional algorithm: right-to-left runs are
+// reversed, with brackets mirrored, and the runs are set right to left, so
+// numbers and Latin words embedded in them still read left to right.
+func visualOrder(line string) string {
+	var p bidi.Paragraph
+	if _, err := p.SetString(line, bidi.DefaultDirection(bidi.RightToLeft)); err != nil {
+		return line
+	}
+	o, err := p.Order()
+	if err != nil {
+		return line
+	}
+	runs := make([]string, o.NumRuns())
+	for i := range runs {
+		r := o.Run(i)
+		runs[i] = r.String()
+		if r.Direction() == bidi.RightToLeft {
+			runs[i] = bidi.ReverseString(runs[i])
+		}
+	}
+	slices.Reverse(runs)
+	return strings.Join(runs, "")
+}
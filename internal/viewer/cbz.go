@@ -0,0 +1,141 @@
+package viewer
+
+import (
+	"archive/zip"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// cbzBackend serves a comic book archive: a zip of images, one per page, in
+// natural filename order.
+type cbzBackend struct {
+	zr    *zip.ReadCloser
+	pages []*zip.File
+}
+
+func newCBZBackend(name string) (*cbzBackend, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	c := &cbzBackend{zr: zr}
+	for _, f := range zr.File {
+		base := path.Base(f.Name)
+		if f.FileInfo().IsDir() || strings.HasPrefix(base, ".") || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		switch strings.ToLower(path.Ext(base)) {
+		case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+			c.pages = append(c.pages, f)
+		}
+	}
+	if len(c.pages) == 0 {
+		zr.Close()
+		return nil, fmt.Errorf("no images in archive")
+	}
+	sort.SliceStable(c.pages, func(i, j int) bool {
+		return naturalLess(c.pages[i].Name, c.pages[j].Name)
+	})
+	return c, nil
+}
+
+func (c *cbzBackend) NumPage() int { return len(c.pages) }
+
+func (c *cbzBackend) Text(n int) (string, error) { return "", nil }
+
+func (c *cbzBackend) Image(n int) (image.Image, error) {
+	if n < 0 || n >= len(c.pages) {
+		return nil, fmt.Errorf("page %d out of range", n)
+	}
+	r, err := c.pages[n].Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
+// ImageDPI treats the image's native size as 72 DPI, matching Bound, and
+// scales it to the requested resolution.
+func (c *cbzBackend) ImageDPI(n int, dpi float64) (image.Image, error) {
+	img, err := c.Image(n)
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	w := int(float64(b.Dx()) * dpi / 72)
+	h := int(float64(b.Dy()) * dpi / 72)
+	if w < 1 || h < 1 || (w == b.Dx() && h == b.Dy()) {
+		return img, nil
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst, nil
+}
+
+// Bound reads only the image header to get the page size.
+func (c *cbzBackend) Bound(n int) (image.Rectangle, error) {
+	if n < 0 || n >= len(c.pages) {
+		return image.Rectangle{}, fmt.Errorf("page %d out of range", n)
+	}
+	r, err := c.pages[n].Open()
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	defer r.Close()
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	return image.Rect(0, 0, cfg.Width, cfg.Height), nil
+}
+
+func (c *cbzBackend) ToC() ([]Chapter, error) { return nil, nil }
+
+func (c *cbzBackend) Close() error { return c.zr.Close() }
+
+// naturalLess compares strings with embedded numbers by value, so that
+// "page2.jpg" sorts before "page10.jpg".
+func naturalLess(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	for a != "" && b != "" {
+		ad, bd := isDigit(a[0]), isDigit(b[0])
+		if ad && bd {
+			an, bn := digitPrefix(a), digitPrefix(b)
+			at, bt := strings.TrimLeft(an, "0"), strings.TrimLeft(bn, "0")
+			if len(at) != len(bt) {
+				return len(at) < len(bt)
+			}
+			if at != bt {
+				return at < bt
+			}
+			a, b = a[len(an):], b[len(bn):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func digitPrefix(s string) string {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i]
+}
@@ -0,0 +1,50 @@
+package viewer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// copier is a program that puts text read from stdin on the clipboard. env,
+// if set, names the variable that must be set for it to work: the display
+// server it talks to.
+type copier struct {
+	name string
+	args []string
+	env  string
+}
+
+// copiers are tried in order; the first one installed that succeeds is used.
+var copiers = []copier{
+	{name: "pbcopy"},
+	{name: "wl-copy", env: "WAYLAND_DISPLAY"},
+	{name: "xclip", args: []string{"-selection", "clipboard"}, env: "DISPLAY"},
+	{name: "xsel", args: []string{"--clipboard", "--input"}, env: "DISPLAY"},
+	{name: "clip.exe"},
+}
+
+// copyText puts text on the clipboard with the first of copiers that works.
+// Without one, as over SSH, it asks the terminal to do it with an OSC 52
+// escape sequence, which most terminals accept; it reports false then, as
+// there is no telling whether this one did.
+func copyText(text string) bool {
+	for _, c := range copiers {
+		if c.name == "pbcopy" && runtime.GOOS != "darwin" || c.env != "" && os.Getenv(c.env) == "" {
+			continue
+		}
+		if _, err := exec.LookPath(c.name); err != nil {
+			continue
+		}
+		cmd := exec.Command(c.name, c.args...)
+		cmd.Stdin = strings.NewReader(text)
+		if cmd.Run() == nil {
+			return true
+		}
+	}
+	fmt.Printf("\033]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return false
+}
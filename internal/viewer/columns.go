@@ -0,0 +1,95 @@
+package viewer
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// columnTexter is implemented by backends that can return a page's text in
+// column reading order rather than in the order it is stored.
+type columnTexter interface {
+	ColumnText(n int) (string, error)
+}
+
+var (
+	htmlPageWidth = regexp.MustCompile(`<div id="page\d+" style="width:([\d.]+)pt`)
+	htmlLine      = regexp.MustCompile(`<p style="top:([\d.]+)pt;left:([\d.]+)pt;line-height:([\d.]+)pt">(.*?)</p>`)
+	htmlTag       = regexp.MustCompile(`<[^>]+>`)
+)
+
+type textLine struct {
+	top, left, height float64
+	text              string
+}
+
+// columnText parses MuPDF's positioned HTML output into lines and orders them
+// by column. A page counts as two-column when a fair share of its lines start
+// on each side of the middle.
+func columnText(page string) string {
+	lines := htmlLines(page)
+	if len(lines) == 0 {
+		return ""
+	}
+
+	width := 0.0
+	if m := htmlPageWidth.FindStringSubmatch(page); m != nil {
+		width, _ = strconv.ParseFloat(m[1], 64)
+	}
+	mid := width / 2
+
+	var left, right []textLine
+	for _, l := range lines {
+		if width > 0 && l.left >= mid {
+			right = append(right, l)
+		} else {
+			left = append(left, l)
+		}
+	}
+	if len(right) < len(lines)/5 || len(left) < len(lines)/5 {
+		return joinLines(lines)
+	}
+	return joinLines(left) + "\n\n" + joinLines(right)
+}
+
+// htmlLines parses the positioned lines out of MuPDF's HTML for a page.
+func htmlLines(page string) []textLine {
+	var lines []textLine
+	for _, m := range htmlLine.FindAllStringSubmatch(page, -1) {
+		text := strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(m[4], "")))
+		if text == "" {
+			continue
+		}
+		top, _ := strconv.ParseFloat(m[1], 64)
+		left, _ := strconv.ParseFloat(m[2], 64)
+		height, _ := strconv.ParseFloat(m[3], 64)
+		lines = append(lines, textLine{top: top, left: left, height: height, text: text})
+	}
+	return lines
+}
+
+// joinLines sorts lines top to bottom and joins them, starting a new
+// paragraph wherever the vertical gap is noticeably larger than a line.
+func joinLines(lines []textLine) string {
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].top != lines[j].top {
+			return lines[i].top < lines[j].top
+		}
+		return lines[i].left < lines[j].left
+	})
+	var b strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			if l.top-prev.top > 1.7*max(prev.height, 1) {
+				b.WriteString("\n\n")
+			} else {
+				b.WriteString("\n")
+			}
+		}
+		b.WriteString(l.text)
+	}
+	return b.String()
+}
@@ -3,13 +3,23 @@ package viewer
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+
+	"pdf-cli/internal/battery"
+	"pdf-cli/internal/theme"
 )
 
 func (d *DocumentViewer) displayCurrentPage() {
 	termWidth, termHeight := d.getTerminalSize()
-	actualPage := d.textPages[d.currentPage]
 
 	// Begin synchronized update (Kitty) - buffers output for atomic display
 	fmt.Print("\033[?2026h")
@@ -25,32 +35,48 @@ func (d *DocumentViewer) displayCurrentPage() {
 	}
 	fmt.Print("\033[1G")
 	fmt.Print("\033[0m")
+	d.pageLines, d.visibleLines, d.imageScroll = 0, 0, false
+	d.rulerStops = d.rulerStops[:0]
+	d.shownLines = nil
+	d.onImagePage = false
+	d.redrawStatus = nil
 
-	if d.dualPageMode == "half" {
-		d.displayHalfPage(termWidth, termHeight)
-		fmt.Print("\033[9999;1H")
-		fmt.Print("\033[?2026l")
-		os.Stdout.Sync()
-		return
+	// The tab bar and thumbnail strip take the top rows. The page is drawn
+	// below them in a scroll region with origin mode on, so its row numbers
+	// count from the top of the region and the page code needn't know about
+	// them.
+	bar := d.tabBarHeight(termHeight)
+	strip := d.thumbStripHeight(termHeight - bar)
+	if top := bar + strip; top > 0 {
+		fmt.Printf("\033[%d;%dr\033[?6h\033[H", top+1, termHeight)
+		termHeight -= top
 	}
-	if d.dualPageMode != "" {
+
+	switch {
+	case d.dualPageMode == "half":
+		d.displayHalfPage(termWidth, termHeight)
+	case d.dualPageMode != "":
 		d.displayDualPage(termWidth, termHeight)
-		fmt.Print("\033[9999;1H")
-		fmt.Print("\033[?2026l")
-		os.Stdout.Sync()
-		return
+	default:
+		actualPage := d.textPages[d.currentPage]
+		switch d.getPageContentType(actualPage) {
+		case "image":
+			d.displayImagePage(actualPage, termWidth, termHeight)
+		case "mixed":
+			d.displayMixedPage(actualPage, termWidth, termHeight)
+		default:
+			d.displayTextPage(actualPage, termWidth, termHeight)
+		}
 	}
 
-	contentType := d.getPageContentType(actualPage)
-	switch contentType {
-	case "text":
-		d.displayTextPage(actualPage, termWidth, termHeight)
-	case "image":
-		d.displayImagePage(actualPage, termWidth, termHeight)
-	case "mixed":
-		d.displayMixedPage(actualPage, termWidth, termHeight)
-	default:
-		d.displayTextPage(actualPage, termWidth, termHeight)
+	if bar+strip > 0 {
+		fmt.Print("\033[?6l\033[r")
+	}
+	if bar > 0 {
+		d.drawTabBar(termWidth)
+	}
+	if strip > 0 {
+		d.drawThumbStrip(termWidth, bar+1, strip)
 	}
 	fmt.Print("\033[9999;1H")
 
@@ -60,6 +86,9 @@ func (d *DocumentViewer) displayCurrentPage() {
 }
 
 func (d *DocumentViewer) getPageContentType(pageNum int) string {
+	if d.fileType == "cbz" {
+		return "image"
+	}
 	if d.forceMode == "text" {
 		return "text"
 	}
@@ -99,6 +128,12 @@ func (d *DocumentViewer) getPageContentType(pageNum int) string {
 }
 
 func (d *DocumentViewer) highlightSearchMatches(line string) string {
+	return d.highlightSearchMatchesStyle(line, "\033[0m"+d.textStyle())
+}
+
+// highlightSearchMatchesStyle is highlightSearchMatches with after written
+// after each match, to restore the line's own style.
+func (d *DocumentViewer) highlightSearchMatchesStyle(line, after string) string {
 	if d.searchQuery == "" {
 		return line
 	}
@@ -117,28 +152,48 @@ func (d *DocumentViewer) highlightSearchMatches(line string) string {
 			break
 		}
 		result.WriteString(line[pos : pos+idx])
-		result.WriteString("\033[43;30m") // yellow bg, black text
+		result.WriteString(d.matchStyle)
 		result.WriteString(line[pos+idx : pos+idx+len(query)])
-		result.WriteString("\033[0m") // reset
+		result.WriteString(after)
 		pos += idx + len(query)
 	}
 	return result.String()
 }
 
 func (d *DocumentViewer) displayTextPage(pageNum, termWidth, termHeight int) {
-	text, err := d.doc.Text(pageNum)
+	text, err := d.pageText(pageNum)
 	if err != nil {
-		fmt.Printf("Error extracting text: %v\n", err)
+		d.displayErrorPage(pageNum, termWidth, termHeight, err)
 		return
 	}
-	effectiveWidth := termWidth - 3
-	reflowedLines := d.reflowText(text, effectiveWidth)
+	margin, effectiveWidth := d.textColumn(termWidth, 1)
+	reflowedLines := d.spaceLines(d.reflowText(text, effectiveWidth))
 	reserved := 2
 	available := termHeight - reserved
 
-	if d.darkMode != "" {
-		fmt.Print("\033[38;2;255;255;255m\033[48;2;30;30;30m")
+	d.pageLines, d.visibleLines = len(reflowedLines), available
+	d.shownLines = reflowedLines
+	if d.selecting && len(reflowedLines) > 0 {
+		d.selStart = min(d.selStart, len(reflowedLines)-1)
+		d.selEnd = min(d.selEnd, len(reflowedLines)-1)
+		d.scrollIntoView(d.selEnd)
+	} else if d.ruler {
+		for i, line := range reflowedLines {
+			if strings.TrimSpace(line) != "" {
+				d.rulerStops = append(d.rulerStops, i)
+			}
+		}
+		d.placeRuler()
+	}
+	if d.lineOffset > len(reflowedLines)-available {
+		d.lineOffset = max(len(reflowedLines)-available, 0)
 	}
+	first := d.lineOffset
+	rtl := rtlLines(reflowedLines)[d.lineOffset:]
+	reflowedLines = reflowedLines[d.lineOffset:]
+
+	style := d.textStyle()
+	fmt.Print(style)
 
 	row := 1
 	for i, line := range reflowedLines {
@@ -146,10 +201,31 @@ func (d *DocumentViewer) displayTextPage(pageNum, termWidth, termHeight int) {
 			break
 		}
 		fmt.Printf("\033[%d;1H", row)
-		if d.darkMode != "" {
-			fmt.Printf("\033[K  %s", d.highlightSearchMatches(line))
+		// Right-to-left paragraphs are reordered for display and set
+		// flush right.
+		if rtl[i] {
+			line = visualOrder(line)
+			line = strings.Repeat(" ", max(effectiveWidth-runewidth.StringWidth(line), 0)) + line
+		}
+		// The ruler dims every line but the focus line, which is bold.
+		attr, reset := "", ""
+		if d.rulerActive() {
+			attr, reset = "\033[2m", "\033[22m"
+			if first+i == d.rulerLine {
+				attr = "\033[1m"
+			}
+		}
+		// Selected lines are reversed, padded to the width so that blank
+		// ones show too.
+		if selFirst, selLast := d.selection(); d.selecting && first+i >= selFirst && first+i <= selLast {
+			line += strings.Repeat(" ", max(effectiveWidth-runewidth.StringWidth(line), 0))
+			attr, reset = attr+"\033[7m", reset+"\033[27m"
+		}
+		line = attr + d.highlightSearchMatchesStyle(line, "\033[0m"+style+attr) + reset
+		if style != "" {
+			fmt.Printf("\033[K%s%s", margin, line)
 		} else {
-			fmt.Printf("  %s", d.highlightSearchMatches(line))
+			fmt.Printf("%s%s", margin, line)
 		}
 		row++
 		if i == len(reflowedLines)-1 {
@@ -158,7 +234,7 @@ func (d *DocumentViewer) displayTextPage(pageNum, termWidth, termHeight int) {
 	}
 	for row <= available {
 		fmt.Printf("\033[%d;1H", row)
-		if d.darkMode != "" {
+		if style != "" {
 			fmt.Print("\033[K")
 		} else {
 			fmt.Print(strings.Repeat(" ", termWidth))
@@ -166,15 +242,57 @@ func (d *DocumentViewer) displayTextPage(pageNum, termWidth, termHeight int) {
 		row++
 	}
 
-	if d.darkMode != "" {
-		fmt.Print("\033[0m")
-	}
 	fmt.Printf("\033[%d;1H", termHeight-1)
-	fmt.Print(strings.Repeat(" ", termWidth))
+	if style != "" {
+		fmt.Print("\033[K\033[0m")
+	} else {
+		fmt.Print(strings.Repeat(" ", termWidth))
+	}
 	fmt.Printf("\033[%d;1H", termHeight)
 	d.displayPageInfo(pageNum, termWidth, "Text")
 }
 
+// rulerActive reports whether the reading ruler is shown on the displayed
+// page: it is on and the page is text with lines to focus.
+func (d *DocumentViewer) rulerActive() bool {
+	return d.ruler && len(d.rulerStops) > 0
+}
+
+// placeRuler moves the ruler onto a line of text, the nearest one below
+// where it was, and scrolls the page to bring it into view.
+func (d *DocumentViewer) placeRuler() {
+	if len(d.rulerStops) == 0 {
+		return
+	}
+	i, _ := slices.BinarySearch(d.rulerStops, d.rulerLine)
+	d.rulerLine = d.rulerStops[min(i, len(d.rulerStops)-1)]
+	d.scrollIntoView(d.rulerLine)
+}
+
+// scrollIntoView scrolls the displayed text page as little as it takes to
+// show line.
+func (d *DocumentViewer) scrollIntoView(line int) {
+	if line < d.lineOffset {
+		d.lineOffset = line
+	} else if line >= d.lineOffset+d.visibleLines {
+		d.lineOffset = line - d.visibleLines + 1
+	}
+}
+
+// textColumn returns the indent and line width for page text on a terminal
+// termWidth columns wide, keeping pad columns free at the right. With
+// max_text_width set, lines are held to that measure and the column is
+// centered, so prose on a wide terminal isn't set full width.
+func (d *DocumentViewer) textColumn(termWidth, pad int) (string, int) {
+	indent := d.textMargin
+	width := max(termWidth-d.textMargin-pad, 10)
+	if d.maxTextWidth > 0 && width > d.maxTextWidth {
+		width = max(d.maxTextWidth, 10)
+		indent = max(d.textMargin, (termWidth-width)/2)
+	}
+	return strings.Repeat(" ", indent), width
+}
+
 func (d *DocumentViewer) displayImagePage(pageNum, termWidth, termHeight int) {
 	reserved := 2
 	verticalPadding := 1
@@ -182,7 +300,11 @@ func (d *DocumentViewer) displayImagePage(pageNum, termWidth, termHeight int) {
 	fmt.Print("\033[1;1H")
 	fmt.Print("\r\n")
 	fmt.Print("\033[2;1H")
-	imageHeight := d.renderPageImage(pageNum, termWidth, availableHeight)
+	imageHeight := d.renderScrollablePageImage(pageNum, termWidth, availableHeight)
+	if imageHeight <= 0 && d.renderErr != nil {
+		d.displayErrorPage(pageNum, termWidth, termHeight, d.renderErr)
+		return
+	}
 	if imageHeight <= 0 {
 		fmt.Print("\033[2;1H")
 		fmt.Printf("  [Image content - page %d]", pageNum+1)
@@ -214,6 +336,12 @@ func (d *DocumentViewer) displayMixedPage(pageNum, termWidth, termHeight int) {
 	fmt.Print("\033[2;1H")
 	imageHeight := d.renderPageImage(pageNum, termWidth, maxImageHeight)
 	if imageHeight <= 0 {
+		if d.renderErr != nil {
+			if _, err := d.pageText(pageNum); err != nil {
+				d.displayErrorPage(pageNum, termWidth, termHeight, d.renderErr)
+				return
+			}
+		}
 		imageHeight = 0
 	}
 	currentRow := imageHeight + 1 + verticalPadding
@@ -226,17 +354,21 @@ func (d *DocumentViewer) displayMixedPage(pageNum, termWidth, termHeight int) {
 	}
 	textAvailable := available - imageHeight - separatorUsed
 	if textAvailable > 0 {
-		text, err := d.doc.Text(pageNum)
+		text, err := d.pageText(pageNum)
 		if err == nil && strings.TrimSpace(text) != "" {
-			effectiveWidth := termWidth - 4
-			reflowedLines := d.reflowText(text, effectiveWidth)
+			margin, effectiveWidth := d.textColumn(termWidth, 2)
+			reflowedLines := d.spaceLines(d.reflowText(text, effectiveWidth))
 			textLinesDisplayed := 0
 			for i, line := range reflowedLines {
 				if textLinesDisplayed >= textAvailable {
 					break
 				}
 				fmt.Printf("\033[%d;1H", currentRow)
-				fmt.Printf("  %s", d.highlightSearchMatches(line))
+				if style := d.textStyle(); style != "" {
+					fmt.Printf("%s\033[K%s%s\033[0m", style, margin, d.highlightSearchMatches(line))
+				} else {
+					fmt.Printf("%s%s", margin, d.highlightSearchMatches(line))
+				}
 				currentRow++
 				textLinesDisplayed++
 				if i == len(reflowedLines)-1 {
@@ -263,6 +395,47 @@ func (d *DocumentViewer) displayMixedPage(pageNum, termWidth, termHeight int) {
 	d.displayPageInfo(pageNum, termWidth, "Image+Text")
 }
 
+// searchIndicator describes the active search for the status line: which
+// match the current page is, like less and vim, or how many pages match
+// when it isn't one of them.
+func (d *DocumentViewer) searchIndicator() string {
+	if d.searchQuery == "" {
+		return ""
+	}
+	if len(d.searchHits) == 0 {
+		return fmt.Sprintf(" [/%s: no matches]", d.searchQuery)
+	}
+	page := d.textPages[d.currentPage]
+	for i, p := range d.searchHits {
+		if p == page {
+			return fmt.Sprintf(" [/%s: match %d/%d]", d.searchQuery, i+1, len(d.searchHits))
+		}
+	}
+	return fmt.Sprintf(" [/%s: %d matches]", d.searchQuery, len(d.searchHits))
+}
+
+// displayErrorPage stands in for a page that could not be extracted or
+// rendered, so one corrupt page doesn't stop the rest of the document from
+// being read. The status line is drawn as usual.
+func (d *DocumentViewer) displayErrorPage(pageNum, termWidth, termHeight int, err error) {
+	reason := runewidth.Truncate(err.Error(), max(termWidth-6, 10), "...")
+	lines := []string{
+		"⚠ This page could not be rendered",
+		"",
+		"(" + reason + ")",
+		"",
+		"The rest of the document can still be read",
+	}
+	top := max((termHeight-2-len(lines))/2, 1)
+	for i, line := range lines {
+		fmt.Printf("\033[%d;1H\033[K", top+i)
+		pad := max((termWidth-runewidth.StringWidth(line))/2, 0)
+		fmt.Print(strings.Repeat(" ", pad) + line)
+	}
+	fmt.Printf("\033[%d;1H", termHeight)
+	d.displayPageInfo(pageNum, termWidth, "Error")
+}
+
 func (d *DocumentViewer) drawSearchMarkers(pageNum, termWidth, topPadding, imageHeight int) {
 	text, err := d.doc.Text(pageNum)
 	if err != nil || strings.TrimSpace(text) == "" {
@@ -274,11 +447,21 @@ func (d *DocumentViewer) drawSearchMarkers(pageNum, termWidth, topPadding, image
 		return
 	}
 
+	// A scrolled image shows only part of the page; place markers over the
+	// whole page and drop those outside the window.
+	span, shift := imageHeight, 0
+	if d.imageScroll {
+		span, shift = d.pageLines, d.lineOffset
+	}
+
 	markerRows := make(map[int]bool)
 	query := d.searchQuery
 	for i, line := range lines {
 		if strings.Contains(strings.ToLower(line), query) {
-			row := topPadding + 1 + int(float64(i)/float64(totalLines)*float64(imageHeight))
+			row := topPadding + 1 + int(float64(i)/float64(totalLines)*float64(span)) - shift
+			if d.imageScroll && (row < topPadding+1 || row > topPadding+imageHeight) {
+				continue
+			}
 			if row < topPadding+1 {
 				row = topPadding + 1
 			}
@@ -291,16 +474,47 @@ func (d *DocumentViewer) drawSearchMarkers(pageNum, termWidth, topPadding, image
 
 	for row := range markerRows {
 		fmt.Printf("\033[%d;%dH", row, termWidth)
-		fmt.Print("\033[43m \033[0m")
+		fmt.Print(theme.Color("\033[43m", "\033[7m") + " \033[0m")
 	}
 }
 
 func (d *DocumentViewer) displayPageInfo(pageNum, termWidth int, contentType string) {
+	d.redrawStatus = func() { d.displayPageInfo(pageNum, termWidth, contentType) }
+	d.beginStatusLine()
+	defer d.endStatusLine()
+	termWidth = d.printClock(termWidth)
 	modeIndicator := ""
 	if d.forceMode != "" {
 		modeIndicator = fmt.Sprintf(" [%s]", d.forceMode)
 	}
+	if d.columns {
+		modeIndicator += " [cols]"
+	}
+	if d.tables {
+		modeIndicator += " [tables]"
+	}
+	if d.selecting {
+		first, last := d.selection()
+		modeIndicator += fmt.Sprintf(" [select %d: Enter copies, Esc cancels]", last-first+1)
+	}
+	if d.allPages {
+		modeIndicator += " [no content detected]"
+	} else if d.includeBlank {
+		modeIndicator += " [all pages]"
+	}
+	if d.scanning && d.liveStatus {
+		modeIndicator += " [checking pages " + d.spinnerFrame() + "]"
+	}
+	if d.reflowMode != "" {
+		modeIndicator += fmt.Sprintf(" [%s]", d.reflowMode)
+	}
+	if d.autoScroll {
+		modeIndicator += fmt.Sprintf(" [auto:%s]", d.autoScrollEvery)
+	}
 	fitIndicator := fmt.Sprintf(" [fit:%s]", d.fitMode)
+	if d.zoom > 1 && d.onImagePage {
+		fitIndicator += fmt.Sprintf(" [zoom:%gx]", d.zoom)
+	}
 	scaleIndicator := ""
 	if d.isReflowable {
 		zoomPct := 595 * 100 / d.htmlPageWidth
@@ -308,6 +522,9 @@ func (d *DocumentViewer) displayPageInfo(pageNum, termWidth int, contentType str
 	} else if d.scaleFactor != 1.0 {
 		scaleIndicator = fmt.Sprintf(" [%.0f%%]", d.scaleFactor*100)
 	}
+	if d.maxDPI != 0 {
+		scaleIndicator += fmt.Sprintf(" [dpi:%.0f]", d.maxDPI)
+	}
 	darkIndicator := ""
 	switch d.darkMode {
 	case "smart":
@@ -315,39 +532,186 @@ func (d *DocumentViewer) displayPageInfo(pageNum, termWidth int, contentType str
 	case "invert":
 		darkIndicator = " [dark:inv]"
 	}
+	darkIndicator += d.toneIndicator()
 	cropIndicator := ""
 	if d.cropTop > 0 || d.cropBottom > 0 || d.cropLeft > 0 || d.cropRight > 0 {
 		cropIndicator = " [crop]"
 	}
-	searchIndicator := ""
-	if d.searchQuery != "" {
-		if len(d.searchHits) > 0 {
-			searchIndicator = fmt.Sprintf(" [/%s: %d/%d]", d.searchQuery, d.searchHitIdx+1, len(d.searchHits))
-		} else {
-			searchIndicator = fmt.Sprintf(" [/%s: no matches]", d.searchQuery)
-		}
-	}
+	searchIndicator := d.searchIndicator()
 	chapterIndicator := ""
+	chapterPrefix := ""
+	title := ""
 	if len(d.chapters) > 0 {
 		d.updateCurrentChapter()
 		ch := d.chapters[d.currentChapter]
-		title := ch.Title
-		if len(title) > 30 {
-			title = title[:27] + "..."
+		title = runewidth.Truncate(ch.Title, 30, "...")
+		if d.fileType == "epub" {
+			// EPUB page numbers are arbitrary, so lead with the chapter.
+			chapterPrefix = fmt.Sprintf("Ch. %d: %s — ", d.currentChapter+1, title)
+		} else {
+			chapterIndicator = fmt.Sprintf(" [Ch %d/%d: %s]", d.currentChapter+1, len(d.chapters), title)
 		}
-		chapterIndicator = fmt.Sprintf(" [Ch %d/%d: %s]", d.currentChapter+1, len(d.chapters), title)
 	}
-	typeLabel := strings.ToUpper(d.fileType)
-	pageInfo := fmt.Sprintf("Page %d/%d (%s)%s%s%s%s%s%s%s - %s", d.currentPage+1, len(d.textPages), contentType, modeIndicator, fitIndicator, scaleIndicator, darkIndicator, cropIndicator, chapterIndicator, searchIndicator, typeLabel)
-	if len(pageInfo) > termWidth {
-		pageInfo = pageInfo[:termWidth-3] + "..."
+	scrollIndicator := ""
+	if d.pageLines > d.visibleLines && d.visibleLines > 0 {
+		last := min(d.lineOffset+d.visibleLines, d.pageLines)
+		scrollIndicator = fmt.Sprintf(" [lines %d-%d/%d]", d.lineOffset+1, last, d.pageLines)
 	}
-	if len(pageInfo) < termWidth {
-		padding := (termWidth - len(pageInfo)) / 2
-		fmt.Printf("%s%s", strings.Repeat(" ", padding), pageInfo)
+	bookmarkIndicator := ""
+	if d.isBookmarked(pageNum) {
+		bookmarkIndicator = " [bookmark]"
+	}
+	filename := filepath.Base(d.path)
+	if d.piped() {
+		filename = "standard input"
+	}
+	pageInfo := formatStatus(d.statusFormat, map[string]string{
+		"page":         strconv.Itoa(d.currentPage + 1),
+		"total":        d.pageTotal(),
+		"doc_page":     d.docPageLabel(pageNum),
+		"percent":      strconv.Itoa(int(d.progress() * 100)),
+		"chapter":      title,
+		"epub_chapter": chapterPrefix,
+		"type":         contentType,
+		"format":       strings.ToUpper(d.fileType),
+		"filename":     filename,
+		"time":         time.Now().Format("15:04"),
+		"flags":        scrollIndicator + bookmarkIndicator + modeIndicator + fitIndicator + scaleIndicator + darkIndicator + cropIndicator + chapterIndicator + searchIndicator,
+	})
+	// The bar is only shown when it fits next to the full page info; on
+	// narrow terminals it is the first thing to go.
+	bar := d.progressBar(termWidth)
+	barWidth := runewidth.StringWidth(bar)
+	infoWidth := runewidth.StringWidth(pageInfo)
+	if barWidth+infoWidth > termWidth {
+		bar, barWidth = "", 0
+	}
+	if infoWidth > termWidth {
+		pageInfo = runewidth.Truncate(pageInfo, termWidth, "...")
+		infoWidth = runewidth.StringWidth(pageInfo)
+	}
+	if barWidth+infoWidth < termWidth {
+		padding := (termWidth - barWidth - infoWidth) / 2
+		fmt.Printf("%s%s%s", strings.Repeat(" ", padding), bar, pageInfo)
 	} else {
-		fmt.Print(pageInfo)
+		fmt.Print(bar + pageInfo)
+	}
+}
+
+// toneIndicator shows the image tone settings that differ from the
+// defaults, e.g. " [gray bri:+0.2 con:+0.3]".
+func (d *DocumentViewer) toneIndicator() string {
+	var parts []string
+	if d.grayscale {
+		parts = append(parts, "gray")
+	}
+	if d.brightness != 0 {
+		parts = append(parts, fmt.Sprintf("bri:%+.1f", d.brightness))
+	}
+	if d.contrast != 0 {
+		parts = append(parts, fmt.Sprintf("con:%+.1f", d.contrast))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(parts, " ") + "]"
+}
+
+// docPageLabel names the document page behind the viewer's page number
+// when skipped blank pages make the two differ, e.g. " [PDF p.52]".
+func (d *DocumentViewer) docPageLabel(pageNum int) string {
+	if pageNum == d.currentPage {
+		return ""
+	}
+	return fmt.Sprintf(" [%s p.%d]", strings.ToUpper(d.fileType), pageNum+1)
+}
+
+// beginStatusLine fills the current row with the theme's status colors.
+func (d *DocumentViewer) beginStatusLine() {
+	if st := theme.Get(d.theme).Status; st != "" {
+		fmt.Print(st + "\033[2K")
+	}
+}
+
+func (d *DocumentViewer) endStatusLine() {
+	if theme.Get(d.theme).Status != "" {
+		fmt.Print("\033[0m")
+	}
+}
+
+// batteryRefresh is how long a battery reading is reused; on macOS each
+// reading runs pmset.
+const batteryRefresh = time.Minute
+
+// statusClock is the clock segment of the status bar, e.g.
+// " [14:05 bat:87%]" (a "+" means charging), or "" with the clock off.
+func (d *DocumentViewer) statusClock() string {
+	if !d.clock {
+		return ""
 	}
+	if time.Since(d.batteryAt) > batteryRefresh {
+		d.batteryAt = time.Now()
+		d.battery = ""
+		if b, ok := battery.Read(); ok {
+			d.battery = fmt.Sprintf(" bat:%d%%", b.Percent)
+			if b.Charging {
+				d.battery += "+"
+			}
+		}
+	}
+	return fmt.Sprintf(" [%s%s]", time.Now().Format("15:04"), d.battery)
+}
+
+// printClock right-aligns the clock on the status line the cursor is on
+// and returns the width left for the rest of the status bar.
+func (d *DocumentViewer) printClock(termWidth int) int {
+	clock := d.statusClock()
+	width := runewidth.StringWidth(clock)
+	if clock == "" || width >= termWidth/2 {
+		return termWidth
+	}
+	fmt.Printf("\033[%dG%s\033[1G", termWidth-width+1, clock)
+	return termWidth - width
+}
+
+// refreshStatus redraws only the status bar, for the live_status setting:
+// the clock stays current and the spinner turns without the page being
+// rendered again.
+func (d *DocumentViewer) refreshStatus() {
+	if d.redrawStatus == nil {
+		return
+	}
+	d.spinner++
+	_, termHeight := d.getTerminalSize()
+	fmt.Print("\033[?2026h")
+	fmt.Printf("\033[%d;1H\033[2K", termHeight)
+	d.redrawStatus()
+	fmt.Print("\033[?2026l")
+}
+
+// spinnerFrame is the busy spinner's current frame.
+func (d *DocumentViewer) spinnerFrame() string {
+	const frames = `|/-\`
+	i := d.spinner % len(frames)
+	return frames[i : i+1]
+}
+
+// progress is how far through the document the current page is, as a
+// fraction.
+func (d *DocumentViewer) progress() float64 {
+	return float64(d.currentPage+1) / float64(len(d.textPages))
+}
+
+// progressBar renders reading progress like "[████░░░░░░] 42% ", sized to a
+// fraction of the terminal width. Returns "" on very narrow terminals.
+func (d *DocumentViewer) progressBar(termWidth int) string {
+	if termWidth < 60 || len(d.textPages) == 0 {
+		return ""
+	}
+	barWidth := min(max(termWidth/8, 5), 20)
+	frac := d.progress()
+	filled := int(frac * float64(barWidth))
+	return fmt.Sprintf("[%s%s] %d%% ", strings.Repeat("█", filled), strings.Repeat("░", barWidth-filled), int(frac*100))
 }
 
 func (d *DocumentViewer) displayHalfPage(termWidth, termHeight int) {
@@ -373,26 +737,34 @@ func (d *DocumentViewer) reflowText(text string, termWidth int) []string {
 		text = d.cleanEpubText(text)
 	}
 	lines := strings.Split(text, "\n")
-	hasShortLines := false
-	shortLineCount := 0
-	for _, line := range lines {
-		if len(strings.TrimSpace(line)) > 0 && len(strings.TrimSpace(line)) < termWidth/2 {
-			shortLineCount++
-		}
+	if !d.keepHyphens {
+		lines = dehyphenate(lines)
+		text = strings.Join(lines, "\n")
 	}
-	if float64(shortLineCount)/float64(len(lines)) > 0.3 {
-		hasShortLines = true
+	var preserve bool
+	switch d.reflowMode {
+	case "reflow":
+		preserve = false
+	case "preserve":
+		preserve = true
+	default:
+		preserve = preserveLineBreaks(lines)
 	}
 	var reflowedLines []string
-	if hasShortLines {
+	if preserve {
 		for _, line := range lines {
 			trimmed := strings.TrimSpace(line)
 			if trimmed == "" {
 				reflowedLines = append(reflowedLines, "")
 				continue
 			}
-			if len(trimmed) > termWidth {
+			if isTableLine(trimmed) {
+				reflowedLines = append(reflowedLines, runewidth.Truncate(trimmed, termWidth, "…"))
+			} else if runewidth.StringWidth(trimmed) > termWidth {
 				wrapped := d.wrapText(trimmed, termWidth)
+				if marker, rest, ok := listMarker(line); ok {
+					wrapped = d.wrapListItem(listIndent(line), marker, rest, termWidth)
+				}
 				reflowedLines = append(reflowedLines, wrapped...)
 			} else {
 				reflowedLines = append(reflowedLines, trimmed)
@@ -405,14 +777,34 @@ func (d *DocumentViewer) reflowText(text string, termWidth int) []string {
 				reflowedLines = append(reflowedLines, "")
 				continue
 			}
-			cleanParagraph := strings.ReplaceAll(paragraph, "\n", " ")
-			cleanParagraph = d.normalizeWhitespace(cleanParagraph)
-			if strings.TrimSpace(cleanParagraph) == "" {
+			// Tables keep their rows, cut off rather than wrapped when
+			// too wide.
+			if rows := strings.Split(strings.Trim(paragraph, "\n"), "\n"); isTableLine(rows[0]) {
+				for _, row := range rows {
+					reflowedLines = append(reflowedLines, runewidth.Truncate(row, termWidth, "…"))
+				}
+				reflowedLines = append(reflowedLines, "")
 				continue
 			}
-			wrappedLines := d.wrapText(cleanParagraph, termWidth)
-			reflowedLines = append(reflowedLines, wrappedLines...)
-			reflowedLines = append(reflowedLines, "")
+			// List items start lines of their own, however the
+			// paragraph around them is joined.
+			wrote := false
+			for _, block := range splitListItems(strings.Split(paragraph, "\n")) {
+				cleanBlock := d.normalizeWhitespace(strings.Join(block, " "))
+				if cleanBlock == "" {
+					continue
+				}
+				if marker, rest, ok := listMarker(block[0]); ok {
+					rest = d.normalizeWhitespace(strings.Join(append([]string{rest}, block[1:]...), " "))
+					reflowedLines = append(reflowedLines, d.wrapListItem(listIndent(block[0]), marker, rest, termWidth)...)
+				} else {
+					reflowedLines = append(reflowedLines, d.wrapText(cleanBlock, termWidth)...)
+				}
+				wrote = true
+			}
+			if wrote {
+				reflowedLines = append(reflowedLines, "")
+			}
 		}
 	}
 	for len(reflowedLines) > 0 && reflowedLines[len(reflowedLines)-1] == "" {
@@ -421,7 +813,175 @@ func (d *DocumentViewer) reflowText(text string, termWidth int) []string {
 	return reflowedLines
 }
 
+// preserveLineBreaks decides whether extracted lines are prose wrapped at
+// the page margin, to be reflowed, or text whose line breaks matter: verse,
+// code, tables of contents. In prose most lines run close to the typical
+// (90th percentile) length, the average is high, and wrapped lines seldom
+// end in punctuation. Prose with many short lines, such as dialogue, still
+// qualifies when those short lines end sentences, since they end paragraphs.
+// Mostly indented text is taken to be code, and text whose lines mostly end
+// in numbers a table or table of contents.
+func preserveLineBreaks(lines []string) bool {
+	var text []string
+	var lengths []int
+	indented := 0
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		text = append(text, trimmed)
+		lengths = append(lengths, utf8.RuneCountInString(trimmed))
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "  ") {
+			indented++
+		}
+	}
+	n := len(text)
+	if n < 3 {
+		return false
+	}
+	if indented*3 > n {
+		return true
+	}
+	numbered := 0
+	for _, line := range text {
+		if c := line[len(line)-1]; c >= '0' && c <= '9' {
+			numbered++
+		}
+	}
+	if numbered*2 > n {
+		return true
+	}
+
+	sorted := append([]int(nil), lengths...)
+	sort.Ints(sorted)
+	typical := sorted[n*9/10]
+
+	total, punct := 0, 0
+	full, fullPunct := 0, 0
+	short, shortEnds := 0, 0
+	for i, line := range text {
+		total += lengths[i]
+		p := endsClause(line)
+		if p {
+			punct++
+		}
+		if lengths[i]*20 >= typical*17 {
+			full++
+			if p {
+				fullPunct++
+			}
+		} else {
+			short++
+			if endsSentence(line) {
+				shortEnds++
+			}
+		}
+	}
+	mean := float64(total) / float64(n)
+
+	switch {
+	case full*2 >= n && mean >= 0.6*float64(typical) && punct*10 < n*6:
+		return false
+	case full*5 >= n && fullPunct*2 < full && shortEnds*10 >= short*7:
+		return false
+	}
+	return true
+}
+
+// endsClause reports whether line ends with any clause punctuation, as
+// lines of verse usually do.
+func endsClause(line string) bool {
+	line = strings.TrimRight(line, "\"'”’»)]")
+	r, _ := utf8.DecodeLastRuneInString(line)
+	return strings.ContainsRune(",;.!?:…", r)
+}
+
+// endsSentence reports whether line ends with sentence punctuation,
+// allowing for closing quotes and brackets.
+func endsSentence(line string) bool {
+	line = strings.TrimRight(line, "\"'”’»)]")
+	r, _ := utf8.DecodeLastRuneInString(line)
+	return strings.ContainsRune(".!?:…", r)
+}
+
+// dehyphenate rejoins words split across lines, as in "informa-" followed by
+// "tion", when the hyphen follows a letter and the next line starts with a
+// lowercase letter. Dashes ("--", "—") and hyphens before capitals or digits
+// are left alone.
+func dehyphenate(lines []string) []string {
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		for i+1 < len(lines) {
+			head := strings.TrimRight(line, " \t")
+			word, ok := strings.CutSuffix(head, "-")
+			if !ok {
+				word, ok = strings.CutSuffix(head, "\u00ad") // soft hyphen
+			}
+			if !ok || word == "" {
+				break
+			}
+			prev, _ := utf8.DecodeLastRuneInString(word)
+			next := strings.TrimLeft(lines[i+1], " \t")
+			first, _ := utf8.DecodeRuneInString(next)
+			if !unicode.IsLetter(prev) || !unicode.IsLower(first) {
+				break
+			}
+			line = word + next
+			i++
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// pageText returns the text to display for a page, with tables laid out as
+// grids or in column reading order when those detections are on and the
+// backend supports them.
+func (d *DocumentViewer) pageText(pageNum int) (string, error) {
+	if tt, ok := d.doc.(tableTexter); ok && d.tables {
+		return tt.TableText(pageNum)
+	}
+	if ct, ok := d.doc.(columnTexter); ok && d.columns {
+		return ct.ColumnText(pageNum)
+	}
+	return d.doc.Text(pageNum)
+}
+
+// textStyle returns the SGR sequence for page text: the theme's colors, or
+// white on dark gray when only image dark mode is on.
+func (d *DocumentViewer) textStyle() string {
+	if t := theme.Get(d.theme); t.Text != "" {
+		return t.Text
+	}
+	if d.darkMode != "" {
+		return theme.Color("\033[38;2;255;255;255m\033[48;2;30;30;30m", "")
+	}
+	return ""
+}
+
+// spaceLines inserts a blank line between text lines when double spacing is
+// on. Paragraph breaks are left as they are.
+func (d *DocumentViewer) spaceLines(lines []string) []string {
+	if d.lineSpacing < 2 {
+		return lines
+	}
+	spaced := make([]string, 0, len(lines)*2)
+	for i, line := range lines {
+		spaced = append(spaced, line)
+		if line != "" && i+1 < len(lines) && lines[i+1] != "" {
+			spaced = append(spaced, "")
+		}
+	}
+	return spaced
+}
+
+// cleanEpubText strips markup and decodes the common entities, with the
+// quotation marks of the document's language when it is one of
+// languageEntities.
 func (d *DocumentViewer) cleanEpubText(text string) string {
+	text = stripTags(text)
 	replacements := map[string]string{
 		"&nbsp;":  " ",
 		"&amp;":   "&",
@@ -436,12 +996,136 @@ func (d *DocumentViewer) cleanEpubText(text string) string {
 		"&#8212;": "—",
 		"&#8211;": "–",
 	}
+	for entity, replacement := range languageEntities[primaryLanguage(d.language)] {
+		replacements[entity] = replacement
+	}
 	for entity, replacement := range replacements {
 		text = strings.ReplaceAll(text, entity, replacement)
 	}
 	return text
 }
 
+// stripTags removes HTML markup left in extracted text. <br> becomes a line
+// break, <p> a new line and </p> (or the end of another block element) a
+// paragraph break. Quoted attribute values may contain '>'. Only known HTML
+// elements count as markup, since the text is usually plain already: a '<'
+// that doesn't start one, as in "a < b" or "vector<int>", is kept.
+func stripTags(text string) string {
+	if !strings.Contains(text, "<") {
+		return text
+	}
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(text, '<')
+		if i < 0 {
+			b.WriteString(text)
+			return b.String()
+		}
+		b.WriteString(text[:i])
+		text = text[i:]
+
+		n := tagEnd(text)
+		if n < 0 || !isMarkup(text[:n]) {
+			b.WriteByte('<')
+			text = text[1:]
+			continue
+		}
+		b.WriteString(tagBreak(text[:n]))
+		text = text[n:]
+	}
+}
+
+// tagEnd returns the length of the tag or comment at the start of s, or -1
+// if s doesn't start with one.
+func tagEnd(s string) int {
+	if strings.HasPrefix(s, "<!--") {
+		if end := strings.Index(s[4:], "-->"); end >= 0 {
+			return 4 + end + 3
+		}
+		return -1
+	}
+	if len(s) < 2 {
+		return -1
+	}
+	c := s[1]
+	if c == '/' || c == '!' || c == '?' {
+		if len(s) < 3 {
+			return -1
+		}
+		c = s[2]
+	}
+	if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+		return -1
+	}
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == '>':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// htmlElements are the element names stripTags takes for markup.
+var htmlElements = map[string]bool{
+	"a": true, "abbr": true, "article": true, "aside": true, "b": true, "big": true,
+	"blockquote": true, "body": true, "br": true, "caption": true, "center": true,
+	"cite": true, "code": true, "col": true, "colgroup": true, "dd": true, "del": true,
+	"div": true, "dl": true, "dt": true, "em": true, "figcaption": true, "figure": true,
+	"font": true, "footer": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "head": true, "header": true, "hr": true, "html": true,
+	"i": true, "img": true, "ins": true, "li": true, "link": true, "mark": true,
+	"meta": true, "nav": true, "ol": true, "p": true, "pre": true, "q": true,
+	"rp": true, "rt": true, "ruby": true, "s": true, "section": true, "small": true,
+	"span": true, "strong": true, "style": true, "sub": true, "sup": true, "svg": true,
+	"table": true, "tbody": true, "td": true, "tfoot": true, "th": true, "thead": true,
+	"title": true, "tr": true, "tt": true, "u": true, "ul": true, "wbr": true,
+}
+
+// isMarkup reports whether tag, as found by tagEnd, is HTML: a comment, a
+// declaration such as <!DOCTYPE> or <?xml?>, or one of htmlElements.
+func isMarkup(tag string) bool {
+	if strings.HasPrefix(tag, "<!") || strings.HasPrefix(tag, "<?") {
+		return true
+	}
+	return htmlElements[tagName(tag)]
+}
+
+// tagName returns the lower-case element name of tag.
+func tagName(tag string) string {
+	name := strings.TrimLeft(tag, "</!?")
+	if end := strings.IndexAny(name, " \t\r\n/>"); end >= 0 {
+		name = name[:end]
+	}
+	return strings.ToLower(name)
+}
+
+// tagBreak returns the whitespace that stands in for tag.
+func tagBreak(tag string) string {
+	closing := strings.HasPrefix(tag, "</")
+	switch tagName(tag) {
+	case "br":
+		return "\n"
+	case "p":
+		if closing {
+			return "\n\n"
+		}
+		return "\n"
+	case "div", "li", "tr", "blockquote", "h1", "h2", "h3", "h4", "h5", "h6":
+		if closing {
+			return "\n\n"
+		}
+	}
+	return ""
+}
+
 func (d *DocumentViewer) normalizeWhitespace(text string) string {
 	var result strings.Builder
 	var lastWasSpace bool
@@ -472,37 +1156,47 @@ func (d *DocumentViewer) wrapText(text string, width int) []string {
 	}
 	var lines []string
 	var currentLine strings.Builder
+	lineWidth := 0
 	for _, word := range words {
-		if len(word) > width {
+		wordWidth := runewidth.StringWidth(word)
+		if wordWidth > width {
 			if currentLine.Len() > 0 {
 				lines = append(lines, currentLine.String())
 				currentLine.Reset()
 			}
-			for len(word) > width {
-				lines = append(lines, word[:width])
-				word = word[width:]
-			}
-			if len(word) > 0 {
-				currentLine.WriteString(word)
+			// Split by display cells so wide (CJK) characters and
+			// multibyte runes are never cut in half.
+			for runewidth.StringWidth(word) > width {
+				head := runewidth.Truncate(word, width, "")
+				if head == "" {
+					_, size := utf8.DecodeRuneInString(word)
+					head = word[:size]
+				}
+				lines = append(lines, head)
+				word = word[len(head):]
 			}
+			currentLine.WriteString(word)
+			lineWidth = runewidth.StringWidth(word)
 			continue
 		}
-		proposedLength := currentLine.Len()
-		if proposedLength > 0 {
-			proposedLength += 1
+		proposedWidth := lineWidth
+		if proposedWidth > 0 {
+			proposedWidth++
 		}
-		proposedLength += len(word)
-		if proposedLength <= width {
+		proposedWidth += wordWidth
+		if proposedWidth <= width {
 			if currentLine.Len() > 0 {
 				currentLine.WriteString(" ")
 			}
 			currentLine.WriteString(word)
+			lineWidth = proposedWidth
 		} else {
 			if currentLine.Len() > 0 {
 				lines = append(lines, currentLine.String())
 				currentLine.Reset()
 			}
 			currentLine.WriteString(word)
+			lineWidth = wordWidth
 		}
 	}
 	if currentLine.Len() > 0 {
@@ -561,16 +1255,21 @@ func (d *DocumentViewer) displayDualHorizontal(page1 int, hasPage2 bool, termWid
 }
 
 func (d *DocumentViewer) displayDualPageInfo(hasPage2 bool, termWidth int, modeLabel string) {
+	d.redrawStatus = func() { d.displayDualPageInfo(hasPage2, termWidth, modeLabel) }
+	d.beginStatusLine()
+	defer d.endStatusLine()
+	termWidth = d.printClock(termWidth)
 	page1Num := d.currentPage + 1
 	page2Num := page1Num + 1
-	totalPages := len(d.textPages)
+	totalPages := d.pageTotal()
 
 	var pageRange string
 	if hasPage2 {
-		pageRange = fmt.Sprintf("Pages %d-%d/%d", page1Num, page2Num, totalPages)
+		pageRange = fmt.Sprintf("Pages %d-%d/%s", page1Num, page2Num, totalPages)
 	} else {
-		pageRange = fmt.Sprintf("Page %d/%d", page1Num, totalPages)
+		pageRange = fmt.Sprintf("Page %d/%s", page1Num, totalPages)
 	}
+	pageRange += d.docPageLabel(d.textPages[d.currentPage])
 
 	fitIndicator := fmt.Sprintf(" [fit:%s]", d.fitMode)
 	scaleIndicator := ""
@@ -580,6 +1279,9 @@ func (d *DocumentViewer) displayDualPageInfo(hasPage2 bool, termWidth int, modeL
 	} else if d.scaleFactor != 1.0 {
 		scaleIndicator = fmt.Sprintf(" [%.0f%%]", d.scaleFactor*100)
 	}
+	if d.maxDPI != 0 {
+		scaleIndicator += fmt.Sprintf(" [dpi:%.0f]", d.maxDPI)
+	}
 	darkIndicator := ""
 	switch d.darkMode {
 	case "smart":
@@ -587,28 +1289,20 @@ func (d *DocumentViewer) displayDualPageInfo(hasPage2 bool, termWidth int, modeL
 	case "invert":
 		darkIndicator = " [dark:inv]"
 	}
+	darkIndicator += d.toneIndicator()
 	cropIndicator := ""
 	if d.cropTop > 0 || d.cropBottom > 0 || d.cropLeft > 0 || d.cropRight > 0 {
 		cropIndicator = " [crop]"
 	}
-	searchIndicator := ""
-	if d.searchQuery != "" {
-		if len(d.searchHits) > 0 {
-			searchIndicator = fmt.Sprintf(" [/%s: %d/%d]", d.searchQuery, d.searchHitIdx+1, len(d.searchHits))
-		} else {
-			searchIndicator = fmt.Sprintf(" [/%s: no matches]", d.searchQuery)
-		}
-	}
+	searchIndicator := d.searchIndicator()
 
 	typeLabel := strings.ToUpper(d.fileType)
 	pageInfo := fmt.Sprintf("%s (Image) [%s]%s%s%s%s%s - %s",
 		pageRange, modeLabel, fitIndicator, scaleIndicator, darkIndicator, cropIndicator, searchIndicator, typeLabel)
 
-	if len(pageInfo) > termWidth {
-		pageInfo = pageInfo[:termWidth-3] + "..."
-	}
-	if len(pageInfo) < termWidth {
-		padding := (termWidth - len(pageInfo)) / 2
+	pageInfo = runewidth.Truncate(pageInfo, termWidth, "...")
+	if infoWidth := runewidth.StringWidth(pageInfo); infoWidth < termWidth {
+		padding := (termWidth - infoWidth) / 2
 		fmt.Printf("%s%s", strings.Repeat(" ", padding), pageInfo)
 	} else {
 		fmt.Print(pageInfo)
@@ -0,0 +1,104 @@
+package viewer
+
+import "testing"
+
+func TestStripTags(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "no markup here", "no markup here"},
+		{"nested", "<div><p>One <em>two <b>three</b></em></p></div>", "\nOne two three\n\n\n\n"},
+		{"self-closing", "line one<br/>line two<br />three<img src=\"a.png\"/>", "line one\nline two\nthree"},
+		{"attribute with >", `<a href="x>y">link</a> text`, "link text"},
+		{"comment", "before<!-- note -->after", "beforeafter"},
+		{"less than", "a < b and c > d", "a < b and c > d"},
+		{"template", "std::vector<int> v;", "std::vector<int> v;"},
+		{"generic pair", "Map<String, Integer> m", "Map<String, Integer> m"},
+		{"comparison", "x<y and y>z", "x<y and y>z"},
+		{"unclosed", "<p unclosed", "<p unclosed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripTags(tt.in); got != tt.want {
+				t.Errorf("stripTags(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreserveLineBreaks(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  bool
+	}{
+		{"prose", []string{
+			"The committee met on Tuesday to review the proposal and, after a",
+			"long discussion of the costs involved, agreed that the work should",
+			"go ahead in the spring once the remaining permits have been granted",
+			"by the council. Several members asked for a clearer schedule before",
+			"the next meeting, and the chair promised to circulate one by Friday.",
+		}, false},
+		{"dialogue", []string{
+			"She looked up from the letter and set it down on the table beside",
+			"the lamp, where the light from the window fell across the strange",
+			"handwriting.",
+			"\"Who sent this?\"",
+			"\"I don't know. It came this morning.\"",
+			"He crossed the room and picked it up, turning it over twice before",
+			"he noticed the postmark and understood at last why she had gone so",
+			"quiet.",
+			"\"Oh.\"",
+		}, false},
+		{"poetry", []string{
+			"Whose woods these are I think I know.",
+			"His house is in the village though;",
+			"He will not see me stopping here",
+			"To watch his woods fill up with snow.",
+		}, true},
+		{"code", []string{
+			"func main() {",
+			"    for i := 0; i < 10; i++ {",
+			"        fmt.Println(i)",
+			"    }",
+			"}",
+		}, true},
+		{"contents", []string{
+			"Introduction 1",
+			"Getting Started 7",
+			"Configuration 23",
+			"Index 101",
+		}, true},
+		{"too short", []string{"One line.", "", "Two lines."}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := preserveLineBreaks(tt.lines); got != tt.want {
+				t.Errorf("preserveLineBreaks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndsSentence(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"It was late.", true},
+		{"Was it late?", true},
+		{"as follows:", true},
+		{"and then…", true},
+		{"\"Stop!\"", true},
+		{"(see above.)", true},
+		{"“Quoted.”", true},
+		{"first, second,", false},
+		{"wrapped in the middle of a", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := endsSentence(tt.line); got != tt.want {
+			t.Errorf("endsSentence(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package viewer
+
+import (
+	"archive/zip"
+	"strings"
+)
+
+// Encryption algorithms that only obfuscate embedded fonts. DRM-free EPUBs
+// use them too, and MuPDF reads such books fine.
+var fontObfuscation = map[string]bool{
+	"http://www.idpf.org/2008/embedding": true,
+	"http://ns.adobe.com/pdf/enc#RC":     true,
+}
+
+// epubDRM reports whether an EPUB is DRM-protected: it has Adobe's
+// META-INF/rights.xml, or META-INF/encryption.xml lists a resource
+// encrypted with anything but font obfuscation.
+func epubDRM(filePath string) bool {
+	f, size, err := openSource(filePath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return false
+	}
+	var rights, encryption bool
+	for _, zf := range zr.File {
+		switch zf.Name {
+		case "META-INF/rights.xml":
+			rights = true
+		case "META-INF/encryption.xml":
+			encryption = true
+		}
+	}
+	if rights {
+		return true
+	}
+	if !encryption {
+		return false
+	}
+
+	var enc struct {
+		Data []struct {
+			Method struct {
+				Algorithm string `xml:"Algorithm,attr"`
+			} `xml:"EncryptionMethod"`
+		} `xml:"EncryptedData"`
+	}
+	if readZipXML(zr, "META-INF/encryption.xml", &enc) != nil {
+		// Unreadable, so assume the worst: it is there for a reason.
+		return true
+	}
+	for _, d := range enc.Data {
+		if !fontObfuscation[strings.TrimSpace(d.Method.Algorithm)] {
+			return true
+		}
+	}
+	return false
+}
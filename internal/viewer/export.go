@@ -0,0 +1,243 @@
+package viewer
+
+import (
+	"bufio"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"pdf-cli/internal/imgutil"
+	"pdf-cli/internal/terminal"
+)
+
+// defaultExportDPI is used for PNG export when no DPI ceiling is configured.
+const defaultExportDPI = 300.0
+
+// exportDPI is the resolution for PNG export: the document's configured max
+// DPI if set, independent of the terminal's cell size.
+func (d *DocumentViewer) exportDPI() float64 {
+	if d.maxDPI != 0 {
+		return d.maxDPI
+	}
+	return defaultExportDPI
+}
+
+// ExportPNG renders the 0-indexed document page pageNum at export DPI, with
+// the current crop applied, and writes it to path as a PNG.
+func (d *DocumentViewer) ExportPNG(pageNum int, path string) error {
+	img, err := d.doc.ImageDPI(pageNum, d.exportDPI())
+	if err != nil {
+		return err
+	}
+	img = imgutil.CropImage(img, d.cropTop, d.cropBottom, d.cropLeft, d.cropRight)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	return file.Close()
+}
+
+// ExportPages writes each content page as dir/page_N.png and returns the
+// paths written. first and last are 1-based document page numbers bounding
+// the export; zero leaves that end open.
+func (d *DocumentViewer) ExportPages(dir string, first, last int) ([]string, error) {
+	d.waitForContentScan()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, pageNum := range d.textPages {
+		if (first > 0 && pageNum+1 < first) || (last > 0 && pageNum+1 > last) {
+			continue
+		}
+		path := filepath.Join(dir, fmt.Sprintf("page_%d.png", pageNum+1))
+		if err := d.ExportPNG(pageNum, path); err != nil {
+			return written, fmt.Errorf("page %d: %v", pageNum+1, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// exportCurrentPage asks for an output path, defaulting to ~/page_N.png,
+// and saves the current page there.
+func (d *DocumentViewer) exportCurrentPage(inputChan <-chan terminal.Key) {
+	pageNum := d.textPages[d.currentPage]
+	def := fmt.Sprintf("~/page_%d.png", pageNum+1)
+	path, ok := d.readLine(inputChan, "Save page as: ", def)
+	if !ok || strings.TrimSpace(path) == "" {
+		return
+	}
+	path = expandHome(strings.TrimSpace(path))
+	if err := d.ExportPNG(pageNum, path); err != nil {
+		d.showMessage(inputChan, "Export failed: "+err.Error())
+		return
+	}
+	d.showMessage(inputChan, fmt.Sprintf("Saved page %d to %s (%.0f DPI)", pageNum+1, path, d.exportDPI()))
+}
+
+// WriteText writes the text of each content page to w, pages separated by a
+// blank line. width > 0 reflows the text to that many columns as the viewer
+// does; otherwise the raw extraction is written, with EPUB entities decoded.
+// first and last bound the pages as in ExportPages.
+func (d *DocumentViewer) WriteText(w io.Writer, first, last, width int) error {
+	d.waitForContentScan()
+	bw := bufio.NewWriter(w)
+	sep := ""
+	for _, pageNum := range d.textPages {
+		if (first > 0 && pageNum+1 < first) || (last > 0 && pageNum+1 > last) {
+			continue
+		}
+		text, err := d.pageText(pageNum)
+		if err != nil {
+			return fmt.Errorf("page %d: %v", pageNum+1, err)
+		}
+		if width > 0 {
+			text = strings.Join(d.reflowText(text, width), "\n")
+		} else if d.fileType == "epub" {
+			text = d.cleanEpubText(text)
+		}
+		text = strings.TrimRight(text, "\n")
+		if text == "" {
+			continue
+		}
+		fmt.Fprintf(bw, "%s%s\n", sep, text)
+		sep = "\n"
+	}
+	return bw.Flush()
+}
+
+// ExportText writes the document text to path; see WriteText.
+func (d *DocumentViewer) ExportText(path string, first, last, width int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := d.WriteText(file, first, last, width); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// exportText asks whether to write raw or reflowed text and where to, then
+// writes the whole document.
+func (d *DocumentViewer) exportText(inputChan <-chan terminal.Key) {
+	termWidth, _ := d.getTerminalSize()
+	width := max(termWidth-1, 20)
+	choice := d.selectFromList(inputChan, "Write document text", []string{
+		"Raw text, as extracted",
+		fmt.Sprintf("Reflowed to %d columns", width),
+	}, 0)
+	if choice < 0 {
+		return
+	}
+	if choice == 0 {
+		width = 0
+	}
+
+	base := strings.TrimSuffix(filepath.Base(d.path), filepath.Ext(d.path))
+	if d.piped() {
+		base = "stdin"
+	}
+	path, ok := d.readLine(inputChan, "Write text to: ", "~/"+base+".txt")
+	if !ok || strings.TrimSpace(path) == "" {
+		return
+	}
+	path = expandHome(strings.TrimSpace(path))
+	if err := d.ExportText(path, 0, 0, width); err != nil {
+		d.showMessage(inputChan, "Export failed: "+err.Error())
+		return
+	}
+	d.showMessage(inputChan, "Wrote text to "+path)
+}
+
+// expandHome replaces a leading "~/" with the user's home directory.
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// listImages lists the images embedded in the current page and saves the
+// one picked, or all of them into a directory.
+func (d *DocumentViewer) listImages(inputChan <-chan terminal.Key) {
+	pageNum := d.textPages[d.currentPage]
+	src, ok := d.doc.(imageSource)
+	if !ok {
+		d.showMessage(inputChan, "Can't extract images from this document")
+		return
+	}
+	images, err := src.EmbeddedImages(pageNum)
+	if err != nil {
+		d.showMessage(inputChan, "Can't read the page's images: "+err.Error())
+		return
+	}
+	if len(images) == 0 {
+		d.showMessage(inputChan, fmt.Sprintf("No embedded images on page %d", pageNum+1))
+		return
+	}
+
+	items := make([]string, 0, len(images)+1)
+	for _, img := range images {
+		size := "unknown size"
+		if img.width > 0 {
+			size = fmt.Sprintf("%d×%d", img.width, img.height)
+		}
+		items = append(items, fmt.Sprintf("%s %s, %s", size, strings.ToUpper(img.ext()), formatSize(int64(len(img.data)))))
+	}
+	if len(images) > 1 {
+		items = append(items, fmt.Sprintf("Save all %d images", len(images)))
+	}
+	title := fmt.Sprintf("%d image(s) on page %d - Enter saves", len(images), pageNum+1)
+	choice := d.selectFromList(inputChan, title, items, 0)
+	if choice < 0 {
+		return
+	}
+
+	if choice < len(images) {
+		img := images[choice]
+		def := fmt.Sprintf("~/page_%d_image_%d.%s", pageNum+1, choice+1, img.ext())
+		path, ok := d.readLine(inputChan, "Save image as: ", def)
+		if !ok || strings.TrimSpace(path) == "" {
+			return
+		}
+		path = expandHome(strings.TrimSpace(path))
+		if err := os.WriteFile(path, img.data, 0o644); err != nil {
+			d.showMessage(inputChan, "Export failed: "+err.Error())
+			return
+		}
+		d.showMessage(inputChan, fmt.Sprintf("Saved image %d to %s", choice+1, path))
+		return
+	}
+
+	dir, ok := d.readLine(inputChan, "Save images to: ", fmt.Sprintf("~/page_%d_images", pageNum+1))
+	if !ok || strings.TrimSpace(dir) == "" {
+		return
+	}
+	dir = expandHome(strings.TrimSpace(dir))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		d.showMessage(inputChan, "Export failed: "+err.Error())
+		return
+	}
+	for i, img := range images {
+		path := filepath.Join(dir, fmt.Sprintf("image_%d.%s", i+1, img.ext()))
+		if err := os.WriteFile(path, img.data, 0o644); err != nil {
+			d.showMessage(inputChan, "Export failed: "+err.Error())
+			return
+		}
+	}
+	d.showMessage(inputChan, fmt.Sprintf("Saved %d images to %s", len(images), dir))
+}
@@ -0,0 +1,244 @@
+//go:build cgo
+
+package viewer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gen2brain/go-fitz"
+
+	"pdf-cli/internal/layout"
+)
+
+// openMuPDF opens PDF, EPUB, DOCX and HTML documents through go-fitz.
+func openMuPDF(path, fileType string, password func(attempt int) (string, bool)) (DocumentBackend, error) {
+	var doc *fitz.Document
+	var err error
+	if path == StdinPath {
+		doc, err = fitz.NewFromMemory(stdinData)
+	} else {
+		doc, err = fitz.New(path)
+	}
+	if errors.Is(err, fitz.ErrNeedsPassword) {
+		return unlock(doc, path, fileType, password)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &fitzBackend{doc: doc, path: path, fileType: fileType}, nil
+}
+
+// unlock authenticates an encrypted document, closing it on failure.
+func unlock(doc *fitz.Document, path, fileType string, password func(attempt int) (string, bool)) (DocumentBackend, error) {
+	if password == nil {
+		doc.Close()
+		return nil, errEncrypted
+	}
+	for attempt := 1; attempt <= maxPasswordAttempts; attempt++ {
+		pw, ok := password(attempt)
+		if !ok {
+			doc.Close()
+			if attempt == 1 {
+				return nil, errEncrypted
+			}
+			return nil, errWrongPassword
+		}
+		if layout.AuthenticatePassword(doc, pw) {
+			return &fitzBackend{doc: doc, path: path, fileType: fileType, password: pw}, nil
+		}
+	}
+	doc.Close()
+	return nil, errWrongPassword
+}
+
+// fitzBackend serves PDF, EPUB, DOCX and HTML through go-fitz.
+type fitzBackend struct {
+	doc      *fitz.Document
+	path     string
+	fileType string
+	password string // the password that unlocked doc, if any
+
+	formsOnce sync.Once
+	forms     *pdfForms // nil if the document has no form
+}
+
+func (f *fitzBackend) NumPage() int { return f.doc.NumPage() }
+
+// Text returns the page text followed by the values of any form fields on
+// the page, which MuPDF doesn't extract.
+func (f *fitzBackend) Text(n int) (string, error) {
+	text, err := f.doc.Text(n)
+	if err != nil {
+		return "", err
+	}
+	return f.withFormText(text, n), nil
+}
+
+// withFormText appends page n's form field values to text.
+func (f *fitzBackend) withFormText(text string, n int) string {
+	if f.fileType != "pdf" {
+		return text
+	}
+	f.formsOnce.Do(func() { f.forms = openForms(f.path, f.password) })
+	if f.forms == nil {
+		return text
+	}
+	form := f.forms.text(n)
+	if form == "" {
+		return text
+	}
+	if strings.TrimSpace(text) == "" {
+		return form
+	}
+	return strings.TrimRight(text, "\n") + "\n\n" + form
+}
+
+func (f *fitzBackend) Image(n int) (image.Image, error) { return f.doc.Image(n) }
+
+func (f *fitzBackend) ImageDPI(n int, dpi float64) (image.Image, error) {
+	return f.doc.ImageDPI(n, dpi)
+}
+
+func (f *fitzBackend) Bound(n int) (image.Rectangle, error) { return f.doc.Bound(n) }
+
+func (f *fitzBackend) Close() error {
+	if f.forms != nil {
+		f.forms.Close()
+	}
+	return f.doc.Close()
+}
+
+// ToC converts the document outline to chapters, resolving EPUB-style URI
+// destinations that MuPDF doesn't map to a page number itself.
+func (f *fitzBackend) ToC() ([]Chapter, error) {
+	outline, err := f.doc.ToC()
+	if err != nil {
+		return nil, err
+	}
+	chapters := make([]Chapter, 0, len(outline))
+	for _, entry := range outline {
+		page := entry.Page
+		if page < 0 && entry.URI != "" {
+			page = layout.ResolveLink(f.doc, entry.URI)
+		}
+		if page < 0 {
+			page = 0
+		}
+		chapters = append(chapters, Chapter{
+			Title: entry.Title,
+			Page:  page,
+			Level: entry.Level,
+		})
+	}
+	return chapters, nil
+}
+
+// Metadata returns the document info fields, plus the declared "language".
+// MuPDF hands back fixed-size NUL-padded buffers, so values are trimmed at
+// the first NUL.
+func (f *fitzBackend) Metadata() map[string]string {
+	meta := f.doc.Metadata()
+	for k, v := range meta {
+		if i := strings.IndexByte(v, 0); i >= 0 {
+			v = v[:i]
+		}
+		meta[k] = strings.TrimSpace(v)
+	}
+	meta["language"] = documentLanguage(f.path, f.fileType, f.password)
+	return meta
+}
+
+// dataImage matches an image inlined as a data URI in MuPDF's HTML output.
+var dataImage = regexp.MustCompile(`<img[^>]*\ssrc="data:image/([a-z0-9.+-]+);base64,([^"]*)"`)
+
+// EmbeddedImages returns the images on page n. go-fitz has no call for them
+// as such, but its HTML output of a page inlines each one as a data URI:
+// JPEGs as embedded, anything else re-encoded as PNG.
+func (f *fitzBackend) EmbeddedImages(n int) ([]embeddedImage, error) {
+	html, err := f.doc.HTML(n, false)
+	if err != nil {
+		return nil, err
+	}
+	var images []embeddedImage
+	for _, m := range dataImage.FindAllStringSubmatch(html, -1) {
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(m[2]), ""))
+		if err != nil {
+			continue
+		}
+		img := embeddedImage{format: m[1], data: data}
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+			img.width, img.height = cfg.Width, cfg.Height
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// Links returns the page's hyperlinks, resolving internal destinations such
+// as "#page=12" or named destinations to page numbers.
+func (f *fitzBackend) Links(n int) ([]Link, error) {
+	raw, err := f.doc.Links(n)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var links []Link
+	for _, l := range raw {
+		if l.URI == "" || seen[l.URI] {
+			continue
+		}
+		seen[l.URI] = true
+		page := -1
+		if !isExternalLink(l.URI) {
+			page = layout.ResolveLink(f.doc, l.URI)
+		}
+		links = append(links, Link{URI: l.URI, Page: page})
+	}
+	return links, nil
+}
+
+// isExternalLink reports whether uri points outside the document, i.e. it
+// has a scheme such as "https:" or "mailto:".
+func isExternalLink(uri string) bool {
+	i := strings.Index(uri, ":")
+	if i <= 0 {
+		return false
+	}
+	for _, c := range uri[:i] {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// ColumnText returns the page text with two-column layouts read left column
+// first, then right. Single-column pages come back in top-to-bottom order.
+func (f *fitzBackend) ColumnText(n int) (string, error) {
+	h, err := f.doc.HTML(n, false)
+	if err != nil {
+		return "", err
+	}
+	return f.withFormText(columnText(h), n), nil
+}
+
+// TableText returns the page text in reading order with the rows of any
+// tables laid out as grids. Detection is a guess; see tableText.
+func (f *fitzBackend) TableText(n int) (string, error) {
+	h, err := f.doc.HTML(n, false)
+	if err != nil {
+		return "", err
+	}
+	return f.withFormText(tableText(h), n), nil
+}
+
+// layout reflows the document to the given page size (HTML only).
+func (f *fitzBackend) layout(w, h, em float64) {
+	layout.LayoutDocument(f.doc, w, h, em)
+}
@@ -0,0 +1,205 @@
+package viewer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Field flags (PDF 32000 table 226) that tell the kinds of button apart.
+const (
+	fieldRadio      = 1 << 15
+	fieldPushButton = 1 << 16
+)
+
+// formField is one filled-in AcroForm field on a page.
+type formField struct {
+	label    string
+	value    string
+	checkbox bool    // value is "x" when ticked, "" when not
+	top      float64 // top edge of the widget, in PDF points from the bottom
+	left     float64
+}
+
+// openForms opens a second, pure-Go reader on a PDF for its form fields,
+// whose values MuPDF's text extraction leaves out. It returns nil if the
+// document has no form or can't be read that way.
+func openForms(path, password string) *pdfForms {
+	f, size, err := openSource(path)
+	if err != nil {
+		return nil
+	}
+	tried := false
+	r, err := pdf.NewReaderEncrypted(f, size, func() string {
+		if tried {
+			return ""
+		}
+		tried = true
+		return password
+	})
+	if err != nil || !hasForm(r) {
+		f.Close()
+		return nil
+	}
+	return &pdfForms{f: f, r: r}
+}
+
+// pdfForms reads form fields through its own reader, for backends that
+// don't otherwise have one.
+type pdfForms struct {
+	f source
+	r *pdf.Reader
+}
+
+func (p *pdfForms) text(n int) string { return formText(p.r, n) }
+
+func (p *pdfForms) Close() error { return p.f.Close() }
+
+// hasForm reports whether the document has any AcroForm fields, so that
+// pages of documents without a form aren't searched for widgets.
+func hasForm(r *pdf.Reader) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return r.Trailer().Key("Root").Key("AcroForm").Key("Fields").Len() > 0
+}
+
+// formText lists the values of the form fields on page n (0-indexed) top to
+// bottom, one labeled line each, ready to append to the page text. Empty
+// fields and push buttons are left out; checkboxes show as "[x]" or "[ ]".
+func formText(r *pdf.Reader, n int) (text string) {
+	// The reader panics on some malformed objects.
+	defer func() {
+		if recover() != nil {
+			text = ""
+		}
+	}()
+	fields := pageFormFields(r.Page(n + 1))
+	if len(fields) == 0 {
+		return ""
+	}
+	lines := make([]string, len(fields))
+	for i, f := range fields {
+		switch {
+		case f.checkbox:
+			lines[i] = fmt.Sprintf("[%1s] %s", f.value, f.label)
+		case f.label == "":
+			lines[i] = f.value
+		default:
+			lines[i] = f.label + ": " + f.value
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pageFormFields collects the widgets on a page, sorted into reading order.
+// A field with several widgets, such as a radio group, is listed once.
+func pageFormFields(page pdf.Page) []formField {
+	annots := page.V.Key("Annots")
+	var fields []formField
+	seen := map[string]bool{}
+	for i := 0; i < annots.Len(); i++ {
+		w := annots.Index(i)
+		if w.Key("Subtype").Name() != "Widget" {
+			continue
+		}
+		value, checkbox, ok := widgetValue(w)
+		if !ok {
+			continue
+		}
+		name := fieldName(w)
+		if name != "" && seen[name] {
+			continue
+		}
+		seen[name] = true
+		rect := w.Key("Rect")
+		fields = append(fields, formField{
+			label:    fieldLabel(w),
+			value:    value,
+			checkbox: checkbox,
+			top:      max(rect.Index(1).Float64(), rect.Index(3).Float64()),
+			left:     min(rect.Index(0).Float64(), rect.Index(2).Float64()),
+		})
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].top != fields[j].top {
+			return fields[i].top > fields[j].top
+		}
+		return fields[i].left < fields[j].left
+	})
+	return fields
+}
+
+// widgetValue formats the value of the field behind widget w; ok is false
+// for fields with nothing worth showing.
+func widgetValue(w pdf.Value) (value string, checkbox, ok bool) {
+	v := inherited(w, "V")
+	switch inherited(w, "FT").Name() {
+	case "Tx":
+		s := strings.TrimSpace(v.Text())
+		return s, false, s != ""
+	case "Ch":
+		var opts []string
+		if v.Kind() == pdf.Array {
+			for i := 0; i < v.Len(); i++ {
+				opts = append(opts, v.Index(i).Text())
+			}
+		} else if s := v.Text(); s != "" {
+			opts = append(opts, s)
+		}
+		return strings.Join(opts, ", "), false, len(opts) > 0
+	case "Btn":
+		flags := inherited(w, "Ff").Int64()
+		if flags&fieldPushButton != 0 {
+			return "", false, false
+		}
+		state := v.Name()
+		if state == "" {
+			state = w.Key("AS").Name()
+		}
+		on := state != "" && state != "Off"
+		if flags&fieldRadio != 0 {
+			return state, false, on
+		}
+		if on {
+			return "x", true, true
+		}
+		return "", true, true
+	}
+	return "", false, false
+}
+
+// fieldLabel prefers the field's tooltip (TU), which form authors fill in
+// with the question, over its internal name (T).
+func fieldLabel(w pdf.Value) string {
+	if tu := strings.TrimSpace(inherited(w, "TU").Text()); tu != "" {
+		return tu
+	}
+	return strings.TrimSpace(inherited(w, "T").Text())
+}
+
+// fieldName is the fully qualified field name, parents first.
+func fieldName(w pdf.Value) string {
+	var parts []string
+	for v, depth := w, 0; !v.IsNull() && depth < 32; v, depth = v.Key("Parent"), depth+1 {
+		if t := v.Key("T").Text(); t != "" {
+			parts = append([]string{t}, parts...)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// inherited looks key up on the widget and then its parent fields, as the
+// inheritable field attributes (FT, V, Ff...) may live on any of them.
+func inherited(w pdf.Value, key string) pdf.Value {
+	for v, depth := w, 0; !v.IsNull() && depth < 32; v, depth = v.Key("Parent"), depth+1 {
+		if x := v.Key(key); !x.IsNull() {
+			return x
+		}
+	}
+	return pdf.Value{}
+}
@@ -2,22 +2,97 @@ package viewer
 
 import (
 	"fmt"
+	"net/url"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
-	"pdf-cli/internal/layout"
+	"github.com/mattn/go-runewidth"
+
+	"pdf-cli/internal/config"
+	"pdf-cli/internal/opener"
+	"pdf-cli/internal/terminal"
+	"pdf-cli/internal/theme"
 )
 
-// handleInput returns: 0 = continue, 1 = quit, -1 = search, -2 = goto page, -3 = help, -4 = debug, -5 = chapter list
-func (d *DocumentViewer) handleInput(c byte) int {
+// handleInput returns: 0 = continue, 1 = quit, -1 = search, -2 = goto page, -3 = help, -4 = debug, -5 = table of contents, -6 = bookmarks, -7 = document info, -8 = follow link, -9 = export page, -10 = write text, -11 = goto document page, -12 = list images, -13 = read aloud, -14 = select lines, -15/-16 = next/previous tab, -17 = open in a new tab
+//
+// Down, Right, PageDown and the mouse wheel act like 'j' (next page), Up, Left
+// and PageUp like 'k' (previous page); with Shift the arrows act like 'J' and
+// 'K'. Keys remapped in config.json are translated to their defaults first,
+// except h/j/k/l on a zoomed image page, which always pan.
+//
+// Digits build a vim-style count: "5j" moves forward 5 pages, "42g" jumps to
+// page 42 and "42G" to document page 42. Any other key discards the count.
+func (d *DocumentViewer) handleInput(c terminal.Key) int {
+	// Panning goes by the key as typed, so h/j/k/l pan a zoomed page under
+	// every key profile.
+	if d.zoom > 1 && d.onImagePage && d.pan(c) {
+		d.autoScroll = false
+		d.count = 0
+		return 0
+	}
+	c = d.keys.translate(c)
+	if d.autoScroll && d.count == 0 {
+		switch c {
+		case '+', '=':
+			d.autoScrollEvery = max(d.autoScrollEvery-autoScrollStep(d.autoScrollEvery), time.Second)
+			return 0
+		case '-':
+			d.autoScrollEvery = min(d.autoScrollEvery+autoScrollStep(d.autoScrollEvery+time.Second), 600*time.Second)
+			return 0
+		case 'a':
+		default:
+			// Taking over by hand pauses it; a resumes.
+			d.autoScroll = false
+		}
+	}
+	if c >= '1' && c <= '9' || c == '0' && d.count > 0 {
+		d.count = min(d.count*10+int(c-'0'), 1000000)
+		return 0
+	}
+	count := d.count
+	d.count = 0
+	if count > 0 {
+		switch c {
+		case 'g':
+			d.currentPage = min(count, len(d.textPages)) - 1
+			d.halfPageOffset = 0
+			d.jumped = true
+			return 0
+		case 'G':
+			d.jumpToPage(count)
+			d.halfPageOffset = 0
+			d.jumped = true
+			return 0
+		case 'j', ' ', terminal.KeyDown, terminal.KeyRight, terminal.KeyPageDown,
+			'k', terminal.KeyUp, terminal.KeyLeft, terminal.KeyPageUp,
+			'J', terminal.KeyShiftDown, terminal.KeyShiftRight,
+			'K', terminal.KeyShiftUp, terminal.KeyShiftLeft:
+			for i := 0; i < count; i++ {
+				d.handleKey(c)
+			}
+			return 0
+		}
+	}
+	return d.handleKey(c)
+}
+
+// handleKey runs the command bound to the built-in key c.
+func (d *DocumentViewer) handleKey(c terminal.Key) int {
 	switch c {
 	case 'q':
 		return 1
 	case 'b':
 		d.wantBack = true
 		return 1
-	case 'j', ' ':
+	case 'j', ' ', terminal.KeyDown, terminal.KeyRight, terminal.KeyPageDown, terminal.KeyWheelDown:
 		if d.dualPageMode == "half" {
 			if d.halfPageOffset == 0 {
 				d.halfPageOffset = 1
@@ -30,7 +105,7 @@ func (d *DocumentViewer) handleInput(c byte) int {
 		} else if d.currentPage < len(d.textPages)-1 {
 			d.currentPage++
 		}
-	case 'k':
+	case 'k', terminal.KeyUp, terminal.KeyLeft, terminal.KeyPageUp, terminal.KeyWheelUp:
 		if d.dualPageMode == "half" {
 			if d.halfPageOffset == 1 {
 				d.halfPageOffset = 0
@@ -43,14 +118,30 @@ func (d *DocumentViewer) handleInput(c byte) int {
 		} else if d.currentPage > 0 {
 			d.currentPage--
 		}
+	case terminal.KeyHome:
+		d.currentPage, d.halfPageOffset = 0, 0
+		d.jumped = true
+	case terminal.KeyEnd:
+		d.currentPage, d.halfPageOffset = len(d.textPages)-1, 0
+		d.jumped = true
 	case 'g':
 		return -2
+	case 'G':
+		return -11
+	case 'o', 127, 8:
+		d.jumpBack()
 	case 'c':
 		return -5
 	case '>':
 		d.nextChapter()
+		d.jumped = true
 	case '<':
 		d.prevChapter()
+		d.jumped = true
+	case 'm':
+		d.toggleBookmark()
+	case '\'':
+		return -6
 	case 'h', '?':
 		return -3
 	case 't':
@@ -68,8 +159,10 @@ func (d *DocumentViewer) handleInput(c byte) int {
 		return -1
 	case 'n':
 		d.nextSearchHit()
+		d.jumped = true
 	case 'N':
 		d.prevSearchHit()
+		d.jumped = true
 	case '+', '=':
 		if d.isReflowable {
 			d.adjustHTMLZoom(-100)
@@ -79,6 +172,10 @@ func (d *DocumentViewer) handleInput(c byte) int {
 				d.scaleFactor = 2.0
 			}
 		}
+	case 'z':
+		d.zoomImage(1)
+	case 'Z':
+		d.zoomImage(-1)
 	case '-', '_':
 		if d.isReflowable {
 			d.adjustHTMLZoom(100)
@@ -88,6 +185,10 @@ func (d *DocumentViewer) handleInput(c byte) int {
 				d.scaleFactor = 0.1
 			}
 		}
+	case '.':
+		d.adjustMaxDPI(50)
+	case ',':
+		d.adjustMaxDPI(-50)
 	case 'r':
 		d.refreshCellSize()
 	case 'S':
@@ -95,6 +196,9 @@ func (d *DocumentViewer) handleInput(c byte) int {
 	case 'P':
 		d.openInExternalApp("Preview")
 	case 'O':
+		if d.piped() {
+			break
+		}
 		absPath, _ := filepath.Abs(d.path)
 		exec.Command("open", "-R", absPath).Start()
 	case 'i':
@@ -109,9 +213,43 @@ func (d *DocumentViewer) handleInput(c byte) int {
 		} else {
 			d.darkMode = "invert"
 		}
+	case 'e':
+		d.adjustTone(&d.brightness, 0.1, -0.5, 0.5)
+	case 'E':
+		d.adjustTone(&d.brightness, -0.1, -0.5, 0.5)
+	case 'y':
+		d.adjustTone(&d.contrast, 0.1, -0.5, 0.8)
+	case 'Y':
+		d.adjustTone(&d.contrast, -0.1, -0.5, 0.8)
+	case 'x':
+		d.grayscale = !d.grayscale
+	case 'u':
+		d.ruler = !d.ruler
+	case 'a':
+		d.autoScroll = !d.autoScroll
 	case 'D':
 		return -4
-	case '2':
+	case 'I':
+		return -7
+	case 'F':
+		return -8
+	case 's':
+		return -9
+	case 'w':
+		return -10
+	case 'X':
+		return -12
+	case 'p':
+		return -13
+	case 'V':
+		return -14
+	case '\t':
+		return -15
+	case terminal.KeyBackTab:
+		return -16
+	case ':':
+		return -17
+	case 'v':
 		switch d.dualPageMode {
 		case "":
 			d.dualPageMode = "vertical"
@@ -123,21 +261,25 @@ func (d *DocumentViewer) handleInput(c byte) int {
 		default:
 			d.dualPageMode = ""
 		}
-	case 'J':
+	case 'J', terminal.KeyShiftDown, terminal.KeyShiftRight:
 		if d.dualPageMode != "" {
 			if d.currentPage < len(d.textPages)-2 {
 				d.currentPage += 2
 			} else if d.currentPage < len(d.textPages)-1 {
 				d.currentPage = len(d.textPages) - 1
 			}
+		} else {
+			d.scrollDown()
 		}
-	case 'K':
+	case 'K', terminal.KeyShiftUp, terminal.KeyShiftLeft:
 		if d.dualPageMode != "" {
 			if d.currentPage >= 2 {
 				d.currentPage -= 2
 			} else {
 				d.currentPage = 0
 			}
+		} else {
+			d.scrollUp()
 		}
 	case '{':
 		d.cropTop = min(d.cropTop+0.02, 0.45)
@@ -149,13 +291,174 @@ func (d *DocumentViewer) handleInput(c byte) int {
 		d.cropRight = min(d.cropRight+0.02, 0.45)
 	case '\\':
 		d.cropTop, d.cropBottom, d.cropLeft, d.cropRight = 0, 0, 0, 0
+	case '(':
+		d.textMargin = max(d.textMargin-1, 0)
+	case ')':
+		d.textMargin = min(d.textMargin+1, 20)
+	case 'M':
+		d.columns = !d.columns
+	case '|':
+		d.tables = !d.tables
+	case 'A':
+		d.toggleAllPages()
+	case 'T':
+		d.thumbnails = !d.thumbnails
+	case 'C':
+		d.theme = theme.Next(d.theme)
+		config.SaveSetting("theme", d.theme)
+	case 'H':
+		d.keepHyphens = !d.keepHyphens
+	case 'R':
+		switch d.reflowMode {
+		case "":
+			d.reflowMode = "reflow"
+		case "reflow":
+			d.reflowMode = "preserve"
+		default:
+			d.reflowMode = ""
+		}
+	case 'L':
+		if d.lineSpacing == 2 {
+			d.lineSpacing = 1
+		} else {
+			d.lineSpacing = 2
+		}
 	case 27:
-		// Do nothing for plain ESC
+		// Plain ESC clears the search and its highlighting.
+		d.searchQuery = ""
+		d.searchHits = nil
+		d.searchHitIdx = 0
 	}
 	return 0
 }
 
+// zoomLevels are the magnifications z and Z step through on image pages.
+var zoomLevels = []float64{1, 1.5, 2, 3, 4, 6}
+
+// zoomImage steps the image page zoom up (dir > 0) or down a level. Zooming
+// in from the whole page starts at its center.
+func (d *DocumentViewer) zoomImage(dir int) {
+	if !d.onImagePage {
+		return
+	}
+	i := 0
+	for i < len(zoomLevels)-1 && zoomLevels[i] < d.zoom {
+		i++
+	}
+	i = min(max(i+dir, 0), len(zoomLevels)-1)
+	if d.zoom <= 1 {
+		d.panX, d.panY = 0.5, 0.5
+	}
+	d.zoom = zoomLevels[i]
+}
+
+// pan moves the zoomed viewport half a screen for h/j/k/l and reports
+// whether c was one of them. The renderer keeps the viewport on the page.
+func (d *DocumentViewer) pan(c terminal.Key) bool {
+	step := 0.5 / d.zoom
+	switch c {
+	case 'h':
+		d.panX -= step
+	case 'l':
+		d.panX += step
+	case 'k':
+		d.panY -= step
+	case 'j':
+		d.panY += step
+	default:
+		return false
+	}
+	return true
+}
+
+// autoScrollStep is how far + and - move the auto-scroll interval from
+// every: by a second up to 10s, by 5s beyond.
+func autoScrollStep(every time.Duration) time.Duration {
+	if every <= 10*time.Second {
+		return time.Second
+	}
+	return 5 * time.Second
+}
+
+// autoAdvance moves on by a screen for auto-scroll: down a text page or
+// fit-width image taller than the screen, keeping a line of the old screen
+// in view, or on to the next page. It returns false at the end of the
+// document.
+func (d *DocumentViewer) autoAdvance() bool {
+	if d.lineOffset+d.visibleLines < d.pageLines {
+		step := max(d.visibleLines-1, 1)
+		if d.imageScroll {
+			step = d.visibleLines
+		}
+		d.lineOffset = min(d.lineOffset+step, d.pageLines-d.visibleLines)
+		return true
+	}
+	prevPage, prevOffset := d.currentPage, d.halfPageOffset
+	d.handleKey(terminal.KeyPageDown)
+	if d.currentPage == prevPage {
+		return d.halfPageOffset != prevOffset
+	}
+	d.skipBlankPages(d.currentPage - prevPage)
+	d.resetPageView()
+	return true
+}
+
+// resetPageView drops the scroll position and zoom when the page changes.
+func (d *DocumentViewer) resetPageView() {
+	d.lineOffset = 0
+	d.rulerLine = 0
+	d.zoom = 1
+}
+
+// scrollStep is how many lines J and K move: one on text pages, a quarter
+// screen on a fit-width image, which redraws the whole page each step.
+func (d *DocumentViewer) scrollStep() int {
+	if d.imageScroll {
+		return max(d.visibleLines/4, 1)
+	}
+	return 1
+}
+
+// scrollDown scrolls a text page, or a fit-width image taller than the
+// screen, moving to the next page once the bottom is visible. With the
+// reading ruler on, it moves the ruler to the next line instead, and the
+// page scrolls to keep it in view.
+func (d *DocumentViewer) scrollDown() {
+	if d.rulerActive() {
+		if i, _ := slices.BinarySearch(d.rulerStops, d.rulerLine+1); i < len(d.rulerStops) {
+			d.rulerLine = d.rulerStops[i]
+			return
+		}
+	} else if d.lineOffset+d.visibleLines < d.pageLines {
+		d.lineOffset = min(d.lineOffset+d.scrollStep(), d.pageLines-d.visibleLines)
+		return
+	}
+	if d.currentPage < len(d.textPages)-1 {
+		d.currentPage++
+	}
+}
+
+// scrollUp scrolls back like scrollDown, moving to the previous page when
+// already at the top.
+func (d *DocumentViewer) scrollUp() {
+	if d.rulerActive() {
+		if i, _ := slices.BinarySearch(d.rulerStops, d.rulerLine); i > 0 {
+			d.rulerLine = d.rulerStops[i-1]
+			return
+		}
+	} else if d.lineOffset > 0 {
+		d.lineOffset = max(d.lineOffset-d.scrollStep(), 0)
+		return
+	}
+	if d.currentPage > 0 {
+		d.currentPage--
+	}
+}
+
 func (d *DocumentViewer) openInExternalApp(appName string) {
+	if d.piped() {
+		return
+	}
 	absPath, _ := filepath.Abs(d.path)
 	page := d.currentPage + 1
 	switch appName {
@@ -190,7 +493,7 @@ func (d *DocumentViewer) toggleViewMode() {
 	}
 }
 
-func (d *DocumentViewer) startSearch(inputChan <-chan byte) {
+func (d *DocumentViewer) startSearch(inputChan <-chan terminal.Key) {
 	_, rows := d.getTerminalSize()
 	fmt.Printf("\033[%d;1H\033[K", rows)
 	fmt.Print("\033[?25h")
@@ -213,7 +516,7 @@ func (d *DocumentViewer) startSearch(inputChan <-chan byte) {
 			}
 		default:
 			if ch >= 32 && ch < 127 {
-				query = append(query, ch)
+				query = append(query, byte(ch))
 				fmt.Printf("%c", ch)
 			}
 		}
@@ -249,28 +552,41 @@ done:
 	}
 }
 
+// nextSearchHit moves to the first matching page after the current one,
+// wrapping around to the first match.
 func (d *DocumentViewer) nextSearchHit() {
 	if len(d.searchHits) == 0 {
 		return
 	}
-	d.searchHitIdx = (d.searchHitIdx + 1) % len(d.searchHits)
-	targetPage := d.searchHits[d.searchHitIdx]
-	for i, p := range d.textPages {
-		if p == targetPage {
-			d.currentPage = i
+	page := d.textPages[d.currentPage]
+	d.searchHitIdx = 0
+	for i, p := range d.searchHits {
+		if p > page {
+			d.searchHitIdx = i
 			break
 		}
 	}
+	d.showSearchHit()
 }
 
+// prevSearchHit moves to the last matching page before the current one,
+// wrapping around to the last match.
 func (d *DocumentViewer) prevSearchHit() {
 	if len(d.searchHits) == 0 {
 		return
 	}
-	d.searchHitIdx--
-	if d.searchHitIdx < 0 {
-		d.searchHitIdx = len(d.searchHits) - 1
+	page := d.textPages[d.currentPage]
+	d.searchHitIdx = len(d.searchHits) - 1
+	for i := len(d.searchHits) - 1; i >= 0; i-- {
+		if d.searchHits[i] < page {
+			d.searchHitIdx = i
+			break
+		}
 	}
+	d.showSearchHit()
+}
+
+func (d *DocumentViewer) showSearchHit() {
 	targetPage := d.searchHits[d.searchHitIdx]
 	for i, p := range d.textPages {
 		if p == targetPage {
@@ -280,7 +596,7 @@ func (d *DocumentViewer) prevSearchHit() {
 	}
 }
 
-func (d *DocumentViewer) showHelp(inputChan <-chan byte) {
+func (d *DocumentViewer) showHelp(inputChan <-chan terminal.Key) {
 	fmt.Print("\033[2J\033[H")
 	termWidth, _ := d.getTerminalSize()
 
@@ -290,44 +606,17 @@ func (d *DocumentViewer) showHelp(inputChan <-chan byte) {
 	p(fmt.Sprintf("%s Viewer Help", strings.ToUpper(d.fileType)))
 	p(strings.Repeat("=", termWidth))
 	p("")
-	p("Navigation:")
-	p("  j/Space/Down/Right  - Next page")
-	p("  k/Up/Left           - Previous page")
-	p("  g                   - Go to specific page")
-	p("  c                   - Show chapter list (Table of Contents)")
-	p("  >                   - Next chapter")
-	p("  <                   - Previous chapter")
-	p("  b                   - Back to file list")
-	p("")
-	p("Search:")
-	p("  /                   - Search text in document")
-	p("  n                   - Next search result")
-	p("  N                   - Previous search result")
-	p("")
-	p("Display:")
-	p("  t                   - Toggle view mode (auto/text/image)")
-	p("  f                   - Cycle fit mode (height/width/auto)")
-	p("  i                   - Toggle dark mode (smart invert, preserves hue)")
-	p("  D                   - Show debug info")
-	p("  +/-                 - Zoom in/out (10%-200%)")
-	p("  2                   - Cycle view (off/vertical/horizontal/half-page)")
-	p("  Shift+Left/Right    - Jump 2 pages (in dual page mode)")
-	p("  Arrow/j/k           - Navigate by half-page (in half-page mode)")
-	p("  r                   - Refresh cell size (after resolution change)")
-	p("")
-	p("Crop (trim page edges, session-only):")
-	p("  {                   - Crop top edge (press multiple times)")
-	p("  }                   - Crop bottom edge")
-	p("  [                   - Crop left edge")
-	p("  ]                   - Crop right edge")
-	p("  \\                   - Reset all crops")
-	p("  d                   - Toggle dark mode (simple color invert)")
-	p("  S                   - Open in Skim")
-	p("  P                   - Open in Preview")
-	p("  O                   - Reveal in Finder")
-	p("  h or ?              - Show this help")
-	p("  q                   - Quit")
-	p("")
+	if d.keys.profile != "" {
+		p(fmt.Sprintf("Key profile: %s (key_profile in config.json)", d.keys.profile))
+		p("")
+	}
+	for _, sec := range keyBindings {
+		p(sec.title + ":")
+		for _, b := range sec.rows {
+			p(fmt.Sprintf("  %-19s - %s", d.keys.label(b), b.desc))
+		}
+		p("")
+	}
 	p("Features:")
 	p("  - Auto-reload when file changes (for LaTeX workflows)")
 	p("  - Text is reflowed to fit terminal width")
@@ -336,96 +625,374 @@ func (d *DocumentViewer) showHelp(inputChan <-chan byte) {
 		p("  - HTML entities are converted to readable text")
 	}
 	p("")
-	p("Supported formats: PDF, EPUB, DOCX, HTML")
+	p("Supported formats: PDF, EPUB, DOCX, HTML, TXT, Markdown, CBZ")
 	p("")
 	p(strings.Repeat("=", termWidth))
 	p("Press any key to return...")
 	<-inputChan
 }
 
-func (d *DocumentViewer) showChapterList(inputChan <-chan byte) {
-	fmt.Print("\033[2J\033[H")
-	termWidth, termHeight := d.getTerminalSize()
-
-	p := func(s string) { fmt.Print(s + "\r\n") }
-
+func (d *DocumentViewer) showTableOfContents(inputChan <-chan terminal.Key) {
 	if len(d.chapters) == 0 {
-		p("No chapters found in this document.")
-		p("")
-		p("Press any key to return...")
-		<-inputChan
+		d.showMessage(inputChan, "No table of contents available")
 		return
 	}
 
 	d.updateCurrentChapter()
 
-	p(strings.Repeat("=", termWidth))
-	p("Table of Contents")
-	p(strings.Repeat("=", termWidth))
-	p("")
-
-	available := termHeight - 7
+	items := make([]string, len(d.chapters))
 	for i, ch := range d.chapters {
-		if i >= available {
-			p(fmt.Sprintf("  ... and %d more chapters", len(d.chapters)-i))
-			break
-		}
 		indent := strings.Repeat("  ", ch.Level-1)
-		marker := "  "
-		if i == d.currentChapter {
-			marker = "> "
+		items[i] = fmt.Sprintf("%s%s (p.%d)", indent, ch.Title, ch.Page+1)
+	}
+
+	idx := d.selectFromList(inputChan, "Table of Contents", items, d.currentChapter)
+	if idx >= 0 {
+		d.currentChapter = idx
+		d.goToChapterPage(d.chapters[idx].Page)
+	}
+}
+
+func (d *DocumentViewer) toggleBookmark() {
+	page := d.textPages[d.currentPage]
+	idx := sort.SearchInts(d.bookmarks, page)
+	if idx < len(d.bookmarks) && d.bookmarks[idx] == page {
+		d.bookmarks = append(d.bookmarks[:idx], d.bookmarks[idx+1:]...)
+		return
+	}
+	d.bookmarks = append(d.bookmarks, 0)
+	copy(d.bookmarks[idx+1:], d.bookmarks[idx:])
+	d.bookmarks[idx] = page
+}
+
+func (d *DocumentViewer) isBookmarked(page int) bool {
+	idx := sort.SearchInts(d.bookmarks, page)
+	return idx < len(d.bookmarks) && d.bookmarks[idx] == page
+}
+
+func (d *DocumentViewer) showBookmarks(inputChan <-chan terminal.Key) {
+	if len(d.bookmarks) == 0 {
+		d.showMessage(inputChan, "No bookmarks yet (press m on a page to add one)")
+		return
+	}
+
+	current := d.textPages[d.currentPage]
+	selected := 0
+	items := make([]string, len(d.bookmarks))
+	for i, page := range d.bookmarks {
+		items[i] = fmt.Sprintf("p.%d  %s", page+1, d.firstLine(page))
+		if page <= current {
+			selected = i
 		}
-		title := ch.Title
-		maxTitleLen := termWidth - len(indent) - len(marker) - 15
-		if maxTitleLen < 20 {
-			maxTitleLen = 20
+	}
+
+	idx := d.selectFromList(inputChan, "Bookmarks", items, selected)
+	if idx >= 0 {
+		d.goToChapterPage(d.bookmarks[idx])
+	}
+}
+
+// firstLine returns the first non-empty line of a page's text, used as a label.
+func (d *DocumentViewer) firstLine(pageNum int) string {
+	text, err := d.doc.Text(pageNum)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// followLink lists the links on the current page. Choosing an internal link
+// jumps to its target; an external one is opened in the browser after
+// confirmation.
+func (d *DocumentViewer) followLink(inputChan <-chan terminal.Key) {
+	var links []Link
+	if ls, ok := d.doc.(linkSource); ok {
+		links, _ = ls.Links(d.textPages[d.currentPage])
+	}
+	if len(links) == 0 {
+		d.showMessage(inputChan, "No links on this page")
+		return
+	}
+
+	items := make([]string, len(links))
+	for i, l := range links {
+		if l.Page >= 0 {
+			items[i] = fmt.Sprintf("→ page %d", l.Page+1)
+		} else {
+			items[i] = l.URI
 		}
-		if len(title) > maxTitleLen {
-			title = title[:maxTitleLen-3] + "..."
+	}
+	idx := d.selectFromList(inputChan, "Links on this page", items, 0)
+	if idx < 0 {
+		return
+	}
+
+	if link := links[idx]; link.Page >= 0 {
+		d.jumpToPage(link.Page + 1)
+	} else {
+		d.openExternalLink(inputChan, link.URI)
+	}
+}
+
+// openExternalLink asks before handing uri to the OS opener. Only web and
+// mail links are offered; other schemes, and any link when there is no
+// opener, are just shown so they can be copied by hand. The document
+// controls uri, so it is shown with control characters escaped.
+func (d *DocumentViewer) openExternalLink(inputChan <-chan terminal.Key, uri string) {
+	shown := printableURI(uri)
+	if !openableURI(uri) {
+		d.showMessage(inputChan, "External link (not opened):\r\n\r\n  "+shown)
+		return
+	}
+	if _, err := opener.Command(uri); err != nil {
+		d.showMessage(inputChan, "External link:\r\n\r\n  "+shown)
+		return
+	}
+	fmt.Print("\033[2J\033[H")
+	fmt.Print("Open external link?\r\n\r\n  " + shown + "\r\n\r\n")
+	fmt.Print("Press y to open, any other key to cancel...")
+	if c := <-inputChan; c != 'y' && c != 'Y' {
+		return
+	}
+	if err := opener.Open(uri); err != nil {
+		d.showMessage(inputChan, "Failed to open link: "+err.Error())
+	}
+}
+
+// openableURI reports whether uri is an http, https or mailto link, the
+// only kinds handed to the OS opener.
+func openableURI(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return u.Opaque != ""
+	}
+	return false
+}
+
+// printableURI escapes the characters of uri that aren't printable, such as
+// terminal escape sequences and bidi overrides, Go-style (\x1b, \u202e).
+func printableURI(uri string) string {
+	var sb strings.Builder
+	for _, r := range uri {
+		if unicode.IsPrint(r) {
+			sb.WriteRune(r)
+		} else {
+			q := strconv.QuoteRuneToASCII(r)
+			sb.WriteString(q[1 : len(q)-1])
 		}
-		p(fmt.Sprintf("%s%s%2d. %s (p.%d)", marker, indent, i+1, title, ch.Page+1))
 	}
+	return sb.String()
+}
 
-	p("")
-	p(strings.Repeat("=", termWidth))
-	fmt.Print("Enter chapter number (or ESC to cancel): ")
+// readLine shows prompt on the bottom row and edits a line of text starting
+// from initial. Returns false if the user cancelled with ESC.
+func (d *DocumentViewer) readLine(inputChan <-chan terminal.Key, prompt, initial string) (string, bool) {
+	_, rows := d.getTerminalSize()
 	fmt.Print("\033[?25h")
+	defer fmt.Print("\033[?25l")
 
-	var input []byte
+	line := []rune(initial)
 	for {
+		fmt.Printf("\033[%d;1H\033[K%s%s", rows, prompt, string(line))
+		ch := <-inputChan
+		switch {
+		case ch == 13 || ch == 10:
+			return string(line), true
+		case ch == 27:
+			return "", false
+		case ch == 127 || ch == 8:
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+			}
+		case ch == 21: // Ctrl+U
+			line = line[:0]
+		case ch >= 32 && ch < terminal.KeyUp:
+			line = append(line, rune(ch))
+		}
+	}
+}
+
+// showMessage clears the screen, prints msg and waits for a key press.
+func (d *DocumentViewer) showMessage(inputChan <-chan terminal.Key, msg string) {
+	fmt.Print("\033[2J\033[H")
+	fmt.Print(msg + "\r\n\r\n")
+	fmt.Print("Press any key to return...")
+	<-inputChan
+}
+
+// selectFromList shows a scrollable list with j/k navigation, in the same
+// style as the file picker. Typing a number moves the selection to that entry.
+// Returns the chosen index, or -1 if the user cancelled with ESC or q.
+func (d *DocumentViewer) selectFromList(inputChan <-chan terminal.Key, title string, items []string, selected int) int {
+	if selected < 0 || selected >= len(items) {
+		selected = 0
+	}
+	offset := 0
+	var number []byte
+
+	for {
+		termWidth, termHeight := d.getTerminalSize()
+		visibleLines := termHeight - 6
+		if visibleLines < 1 {
+			visibleLines = 1
+		}
+		if selected < offset {
+			offset = selected
+		} else if selected >= offset+visibleLines {
+			offset = selected - visibleLines + 1
+		}
+
+		fmt.Print("\033[2J\033[H")
+		fmt.Print(strings.Repeat("=", termWidth) + "\r\n")
+		fmt.Print(title + "\r\n")
+		fmt.Print(strings.Repeat("=", termWidth) + "\r\n")
+
+		end := offset + visibleLines
+		if end > len(items) {
+			end = len(items)
+		}
+		maxLen := termWidth - 8
+		if maxLen < 10 {
+			maxLen = 10
+		}
+		for i := offset; i < end; i++ {
+			label := fmt.Sprintf("%2d. %s", i+1, items[i])
+			label = runewidth.Truncate(label, maxLen, "...")
+			if i == selected {
+				fmt.Print("\033[7m► " + label + "\033[0m\r\n")
+			} else {
+				fmt.Print("  " + label + "\r\n")
+			}
+		}
+
+		fmt.Printf("\033[%d;1H", termHeight-1)
+		if len(items) > visibleLines {
+			fmt.Printf("\033[2m  [%d-%d of %d]\033[0m", offset+1, end, len(items))
+		}
+		fmt.Printf("\033[%d;1H", termHeight)
+		fmt.Print("\033[2m  j/k: Navigate  Enter: Jump  0-9: Select by number  Esc/q: Cancel\033[0m")
+
 		ch := <-inputChan
 		switch ch {
 		case 13, 10:
-			goto selectChapter
-		case 27:
-			fmt.Print("\033[?25l")
-			return
-		case 127, 8:
-			if len(input) > 0 {
-				input = input[:len(input)-1]
-				_, rows := d.getTerminalSize()
-				fmt.Printf("\033[%d;1H\033[K", rows)
-				fmt.Printf("Enter chapter number (or ESC to cancel): %s", string(input))
+			return selected
+		case 27, 'q':
+			return -1
+		case 'j', terminal.KeyDown, terminal.KeyWheelDown:
+			if selected < len(items)-1 {
+				selected++
+			}
+			number = nil
+		case 'k', terminal.KeyUp, terminal.KeyWheelUp:
+			if selected > 0 {
+				selected--
 			}
+			number = nil
 		default:
 			if ch >= '0' && ch <= '9' {
-				input = append(input, ch)
-				fmt.Printf("%c", ch)
+				number = append(number, byte(ch))
+				var num int
+				if _, err := fmt.Sscanf(string(number), "%d", &num); err == nil && num >= 1 && num <= len(items) {
+					selected = num - 1
+				} else {
+					number = []byte{byte(ch)}
+				}
 			}
 		}
 	}
-selectChapter:
-	fmt.Print("\033[?25l")
-	var num int
-	if _, err := fmt.Sscanf(string(input), "%d", &num); err == nil {
-		if num >= 1 && num <= len(d.chapters) {
-			d.currentChapter = num - 1
-			d.goToChapterPage(d.chapters[d.currentChapter].Page)
+}
+
+// showInfo prints the file details and document metadata.
+func (d *DocumentViewer) showInfo(inputChan <-chan terminal.Key) {
+	fmt.Print("\033[2J\033[H")
+
+	orDash := func(s string) string {
+		if strings.TrimSpace(s) == "" {
+			return "—"
+		}
+		return s
+	}
+	p := func(label, value string) { fmt.Printf("%-14s %s\r\n", label+":", orDash(value)) }
+
+	absPath, _ := filepath.Abs(d.path)
+	size := ""
+	if d.piped() {
+		absPath, size = "standard input", formatSize(int64(len(stdinData)))
+	} else if info, err := os.Stat(d.path); err == nil {
+		size = formatSize(info.Size())
+	}
+
+	fmt.Print("=== Document Info ===\r\n")
+	p("File", absPath)
+	p("Format", strings.ToUpper(d.fileType))
+	p("Size", size)
+	if d.scanning {
+		p("Pages", fmt.Sprintf("%d total (still checking for blank pages)", d.doc.NumPage()))
+	} else {
+		p("Pages", fmt.Sprintf("%d with content, %d total", len(d.textPages), d.doc.NumPage()))
+	}
+	if words, ok := d.wordStats(); ok {
+		p("Words", fmt.Sprintf("%d (about %s to read at 200 wpm)", words, readingTime(words)))
+	} else {
+		p("Words", "counting…")
+	}
+
+	if ms, ok := d.doc.(metadataSource); ok {
+		meta := ms.Metadata()
+		fmt.Print("\r\n")
+		for _, f := range []struct{ key, label string }{
+			{"title", "Title"},
+			{"author", "Author"},
+			{"subject", "Subject"},
+			{"keywords", "Keywords"},
+			{"language", "Language"},
+			{"creator", "Creator"},
+			{"producer", "Producer"},
+			{"creationDate", "Created"},
+			{"modDate", "Modified"},
+			{"format", "Version"},
+			{"encryption", "Encryption"},
+		} {
+			p(f.label, meta[f.key])
 		}
 	}
+
+	fmt.Print("\r\nPress any key to return...")
+	<-inputChan
 }
 
-func (d *DocumentViewer) showDebugInfo(inputChan <-chan byte) {
+// readingTime formats the time to read words at 200 words per minute.
+func readingTime(words int) string {
+	minutes := (words + 199) / 200
+	if minutes < 60 {
+		return fmt.Sprintf("%d min", minutes)
+	}
+	return fmt.Sprintf("%d h %d min", minutes/60, minutes%60)
+}
+
+// formatSize renders a byte count as B, KB or MB.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+func (d *DocumentViewer) showDebugInfo(inputChan <-chan terminal.Key) {
 	fmt.Print("\033[2J\033[H")
 	cols, rows := d.getTerminalSize()
 	cellW, cellH := d.getTerminalCellSize()
@@ -439,16 +1006,37 @@ func (d *DocumentViewer) showDebugInfo(inputChan <-chan byte) {
 	p(fmt.Sprintf("Calculated terminal pixels: %.0f x %.0f", float64(cols)*cellW, float64(rows)*cellH))
 	p(fmt.Sprintf("Fit mode: %s", d.fitMode))
 	p(fmt.Sprintf("Scale factor: %.1f", d.scaleFactor))
+	p(fmt.Sprintf("Max DPI: %.0f", d.effectiveMaxDPI(d.detectTerminalType())))
 	p("")
 	p("Press any key to return...")
 	<-inputChan
 }
 
-func (d *DocumentViewer) goToPage(inputChan <-chan byte) {
+func (d *DocumentViewer) goToPage(inputChan <-chan terminal.Key) {
+	prompt := fmt.Sprintf("Go to page (1-%d): ", len(d.textPages))
+	if num, ok := d.readPageNumber(inputChan, prompt); ok && num >= 1 && num <= len(d.textPages) {
+		d.currentPage = num - 1
+	}
+}
+
+// goToDocPage jumps to a page by its number in the document, counting the
+// blank pages the viewer skips, as a printed edition would. A skipped page
+// lands on the next one with content.
+func (d *DocumentViewer) goToDocPage(inputChan <-chan terminal.Key) {
+	n := d.doc.NumPage()
+	prompt := fmt.Sprintf("Go to %s page, counting blank pages (1-%d): ", strings.ToUpper(d.fileType), n)
+	if num, ok := d.readPageNumber(inputChan, prompt); ok && num >= 1 && num <= n {
+		d.jumpToPage(num)
+	}
+}
+
+// readPageNumber reads a number on the status line; ok is false if the
+// prompt was cancelled with Esc or nothing was typed.
+func (d *DocumentViewer) readPageNumber(inputChan <-chan terminal.Key, prompt string) (int, bool) {
 	_, rows := d.getTerminalSize()
 	fmt.Printf("\033[%d;1H\033[K", rows)
 	fmt.Print("\033[?25h")
-	fmt.Printf("Go to page (1-%d): ", len(d.textPages))
+	fmt.Print(prompt)
 
 	var input []byte
 	for {
@@ -458,16 +1046,16 @@ func (d *DocumentViewer) goToPage(inputChan <-chan byte) {
 			goto done
 		case 27:
 			fmt.Print("\033[?25l")
-			return
+			return 0, false
 		case 127, 8:
 			if len(input) > 0 {
 				input = input[:len(input)-1]
 				fmt.Printf("\033[%d;1H\033[K", rows)
-				fmt.Printf("Go to page (1-%d): %s", len(d.textPages), string(input))
+				fmt.Printf("%s%s", prompt, string(input))
 			}
 		default:
 			if ch >= '0' && ch <= '9' {
-				input = append(input, ch)
+				input = append(input, byte(ch))
 				fmt.Printf("%c", ch)
 			}
 		}
@@ -475,53 +1063,95 @@ func (d *DocumentViewer) goToPage(inputChan <-chan byte) {
 done:
 	fmt.Print("\033[?25l")
 	var num int
-	if _, err := fmt.Sscanf(string(input), "%d", &num); err == nil {
-		if num >= 1 && num <= len(d.textPages) {
-			d.currentPage = num - 1
-		}
+	if _, err := fmt.Sscanf(string(input), "%d", &num); err != nil {
+		return 0, false
 	}
+	return num, true
 }
 
 // loadChapters extracts the table of contents from the document.
 func (d *DocumentViewer) loadChapters() {
-	outline, err := d.doc.ToC()
-	if err != nil || len(outline) == 0 {
-		d.chapters = nil
+	chapters, err := d.doc.ToC()
+	if err != nil || len(chapters) == 0 {
+		d.chapters, d.chapterStarts = nil, nil
 		return
 	}
-	d.chapters = make([]Chapter, 0, len(outline))
-	for _, entry := range outline {
-		page := entry.Page
-		if page < 0 && entry.URI != "" {
-			page = layout.ResolveLink(d.doc, entry.URI)
-		}
-		if page < 0 {
-			page = 0
+	d.chapters = chapters
+	d.chapterStarts = nil
+	if d.fileType == "epub" {
+		d.chapterStarts = topLevelStarts(chapters)
+	}
+}
+
+// topLevelStarts returns the distinct first pages of the outermost ToC
+// entries. In a book these are the chapters; the entries below them are
+// sections within a chapter.
+func topLevelStarts(chapters []Chapter) []int {
+	top := chapters[0].Level
+	for _, ch := range chapters {
+		top = min(top, ch.Level)
+	}
+	var starts []int
+	for _, ch := range chapters {
+		if ch.Level == top && ch.Page >= 0 {
+			starts = append(starts, ch.Page)
 		}
-		d.chapters = append(d.chapters, Chapter{
-			Title: entry.Title,
-			Page:  page,
-			Level: entry.Level,
-		})
 	}
+	sort.Ints(starts)
+	return slices.Compact(starts)
 }
 
+// updateCurrentChapter finds the chapter enclosing the current page. Chapters
+// are in ToC order, which follows page order, so a binary search suffices.
 func (d *DocumentViewer) updateCurrentChapter() {
 	if len(d.chapters) == 0 {
 		return
 	}
 	actualPage := d.textPages[d.currentPage]
-	d.currentChapter = 0
-	for i, ch := range d.chapters {
-		if ch.Page <= actualPage {
-			d.currentChapter = i
-		} else {
-			break
-		}
+	next := sort.Search(len(d.chapters), func(i int) bool {
+		return d.chapters[i].Page > actualPage
+	})
+	d.currentChapter = max(next-1, 0)
+}
+
+// maxJumpHistory bounds the back stack; the oldest entries go first.
+const maxJumpHistory = 100
+
+// pushJump records the document page the reader jumped away from, so that
+// jumpBack can return to it. Paging with j/k doesn't count as a jump.
+func (d *DocumentViewer) pushJump(page int) {
+	if n := len(d.jumpHistory); n > 0 && d.jumpHistory[n-1] == page {
+		return
 	}
+	if len(d.jumpHistory) == maxJumpHistory {
+		d.jumpHistory = d.jumpHistory[1:]
+	}
+	d.jumpHistory = append(d.jumpHistory, page)
 }
 
+// jumpBack returns to the page before the last jump, like a browser's back
+// button.
+func (d *DocumentViewer) jumpBack() {
+	n := len(d.jumpHistory)
+	if n == 0 {
+		return
+	}
+	page := d.jumpHistory[n-1]
+	d.jumpHistory = d.jumpHistory[:n-1]
+	d.jumpToPage(page + 1)
+	d.halfPageOffset = 0
+}
+
+// nextChapter goes to the next ToC entry, or in an EPUB to the start of the
+// next chapter, skipping the sections within this one.
 func (d *DocumentViewer) nextChapter() {
+	if len(d.chapterStarts) > 0 {
+		page := d.textPages[d.currentPage]
+		if i := sort.SearchInts(d.chapterStarts, page+1); i < len(d.chapterStarts) {
+			d.goToChapterPage(d.chapterStarts[i])
+		}
+		return
+	}
 	if len(d.chapters) == 0 {
 		return
 	}
@@ -532,7 +1162,16 @@ func (d *DocumentViewer) nextChapter() {
 	}
 }
 
+// prevChapter goes to the previous ToC entry, or in an EPUB to the start of
+// the previous chapter.
 func (d *DocumentViewer) prevChapter() {
+	if len(d.chapterStarts) > 0 {
+		page := d.textPages[d.currentPage]
+		if i := sort.SearchInts(d.chapterStarts, page+1) - 1; i > 0 {
+			d.goToChapterPage(d.chapterStarts[i-1])
+		}
+		return
+	}
 	if len(d.chapters) == 0 {
 		return
 	}
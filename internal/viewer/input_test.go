@@ -0,0 +1,40 @@
+package viewer
+
+import "testing"
+
+func TestOpenableURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want bool
+	}{
+		{"https://example.com/paper", true},
+		{"HTTP://example.com", true},
+		{"mailto:someone@example.com", true},
+		{"file:///etc/passwd", false},
+		{"javascript:alert(1)", false},
+		{"smb://server/share", false},
+		{"/usr/bin/xterm", false},
+		{"https:///no-host", false},
+		{"mailto:", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := openableURI(tt.uri); got != tt.want {
+			t.Errorf("openableURI(%q) = %v, want %v", tt.uri, got, tt.want)
+		}
+	}
+}
+
+func TestPrintableURI(t *testing.T) {
+	tests := []struct{ uri, want string }{
+		{"https://example.com/ä?q=1", "https://example.com/ä?q=1"},
+		{"https://example.com/\x1b[2J", `https://example.com/\x1b[2J`},
+		{"https://a.com/\r\nfake", `https://a.com/\r\nfake`},
+		{"https://evil.com/\u202efdp.exe", `https://evil.com/\u202efdp.exe`},
+	}
+	for _, tt := range tests {
+		if got := printableURI(tt.uri); got != tt.want {
+			t.Errorf("printableURI(%q) = %q, want %q", tt.uri, got, tt.want)
+		}
+	}
+}
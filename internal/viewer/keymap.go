@@ -0,0 +1,218 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"pdf-cli/internal/terminal"
+)
+
+// binding is one row of the key table. Rows with an action name can be
+// remapped from the "keys" object in config.json; label is the text shown in
+// help and must start with the default key when the row is remappable.
+type binding struct {
+	action string
+	key    terminal.Key
+	label  string
+	desc   string
+}
+
+type bindingSection struct {
+	title string
+	rows  []binding
+}
+
+var keyBindings = []bindingSection{
+	{"Navigation", []binding{
+		{"next_page", 'j', "j/Space/Down/Right", "Next page (also PageDown)"},
+		{"prev_page", 'k', "k/Up/Left", "Previous page (also PageUp)"},
+		{"", 0, "Home/End", "First/last page"},
+		{"scroll_down", 'J', "J", "Scroll down a line (a quarter screen on tall fit-width images)"},
+		{"scroll_up", 'K', "K", "Scroll up a line (a quarter screen on tall fit-width images)"},
+		{"", 0, "<count>j/k/J/K", "Repeat a movement, e.g. 5j moves forward 5 pages"},
+		{"goto_page", 'g', "g", "Go to specific page (<count>g jumps straight to that page)"},
+		{"goto_doc_page", 'G', "G", "Go to a page by its number in the file, counting skipped blank pages (<count>G too)"},
+		{"jump_back", 'o', "o/Backspace", "Back to where you were before the last jump (goto, search, ToC, link...)"},
+		{"toc", 'c', "c", "Table of contents (j/k to scroll, Enter to jump)"},
+		{"next_chapter", '>', ">", "Next chapter (in EPUBs the next top-level chapter, skipping sections)"},
+		{"prev_chapter", '<', "<", "Previous chapter"},
+		{"bookmark", 'm', "m", "Toggle bookmark on current page"},
+		{"bookmarks", '\'', "'", "Show bookmarks"},
+		{"follow_link", 'F', "F", "Follow a link on the current page (external links open in the browser)"},
+		{"back", 'b', "b", "Back to file list"},
+		{"open_tab", ':', ":", "Open a file in a new tab"},
+		{"", 0, "Tab/Shift+Tab", "Switch to the next/previous tab"},
+	}},
+	{"Search", []binding{
+		{"search", '/', "/", "Search text in document"},
+		{"next_match", 'n', "n", "Next search result"},
+		{"prev_match", 'N', "N", "Previous search result"},
+		{"", 0, "Esc", "Clear the search"},
+	}},
+	{"Display", []binding{
+		{"view_mode", 't', "t", "Toggle view mode (auto/text/image)"},
+		{"fit_mode", 'f', "f", "Cycle fit mode (height/width/auto); tall fit-width pages scroll with J/K"},
+		{"smart_dark", 'i', "i", "Toggle dark mode (smart invert, preserves hue)"},
+		{"debug", 'D', "D", "Show debug info"},
+		{"info", 'I', "I", "Show document info (title, author, pages, size)"},
+		{"zoom_in", '+', "+", "Zoom in (10%-200%)"},
+		{"zoom_out", '-', "-", "Zoom out"},
+		{"page_zoom_in", 'z', "z", "Magnify an image page (h/j/k/l pan while zoomed)"},
+		{"page_zoom_out", 'Z', "Z", "Magnify less (back to the whole page)"},
+		{"dpi_up", '.', ".", "Raise max render DPI (72-400)"},
+		{"dpi_down", ',', ",", "Lower max render DPI"},
+		{"margin_narrow", '(', "(", "Narrow text left margin (0-20)"},
+		{"margin_widen", ')', ")", "Widen text left margin"},
+		{"line_spacing", 'L', "L", "Toggle double line spacing for text"},
+		{"ruler", 'u', "u", "Toggle reading ruler (dims all but one line; J/K move it)"},
+		{"autoscroll", 'a', "a", "Toggle auto-scroll (+/- change its speed, other keys pause it)"},
+		{"reflow_mode", 'R', "R", "Cycle line handling (auto/reflow/preserve line breaks)"},
+		{"hyphens", 'H', "H", "Toggle rejoining words hyphenated across lines"},
+		{"columns", 'M', "M", "Toggle column detection (two-column PDFs)"},
+		{"tables", '|', "|", "Toggle table detection (draws aligned cells as a grid)"},
+		{"all_pages", 'A', "A", "Toggle showing every page, blank ones included"},
+		{"dual_page", 'v', "v", "Cycle view (off/vertical/horizontal/half-page)"},
+		{"thumbnails", 'T', "T", "Toggle a strip of page thumbnails at the top (graphics terminals only)"},
+		{"", 0, "Shift+Left/Right", "Jump 2 pages (in dual page mode)"},
+		{"", 0, "Arrow/j/k", "Navigate by half-page (in half-page mode)"},
+		{"refresh", 'r', "r", "Refresh cell size (after resolution change)"},
+	}},
+	{"Crop (trim page edges, session-only)", []binding{
+		{"crop_top", '{', "{", "Crop top edge (press multiple times)"},
+		{"crop_bottom", '}', "}", "Crop bottom edge"},
+		{"crop_left", '[', "[", "Crop left edge"},
+		{"crop_right", ']', "]", "Crop right edge"},
+		{"crop_reset", '\\', "\\", "Reset all crops"},
+		{"dark_mode", 'd', "d", "Toggle dark mode (simple color invert)"},
+		{"brighter", 'e', "e", "Brighten image pages (E darkens)"},
+		{"darker", 'E', "E", "Darken image pages"},
+		{"more_contrast", 'y', "y", "More contrast on image pages, for faint scans (Y for less)"},
+		{"less_contrast", 'Y', "Y", "Less contrast on image pages"},
+		{"grayscale", 'x', "x", "Toggle grayscale image pages"},
+		{"theme", 'C', "C", "Cycle color theme (default/dark/light/sepia/contrast)"},
+		{"open_skim", 'S', "S", "Open in Skim"},
+		{"open_preview", 'P', "P", "Open in Preview"},
+		{"reveal", 'O', "O", "Reveal in Finder"},
+		{"export_png", 's', "s", "Save current page as PNG (Ctrl+U clears the path)"},
+		{"write_text", 'w', "w", "Write document text to a file (raw or reflowed)"},
+		{"images", 'X', "X", "List the images embedded in the page, to save them"},
+		{"speak", 'p', "p", "Read the page aloud (press again to stop)"},
+		{"select", 'V', "V", "Select lines on a text page to copy (j/k move the end, Enter copies)"},
+		{"help", 'h', "h or ?", "Show this help"},
+		{"quit", 'q', "q", "Quit"},
+	}},
+}
+
+// KeyHelp returns the default key bindings for the --help text: each
+// section's title and then its rows, indented, with a blank line after each
+// section.
+func KeyHelp() string {
+	var sb strings.Builder
+	for _, sec := range keyBindings {
+		fmt.Fprintf(&sb, "    %s:\n", sec.title)
+		for _, b := range sec.rows {
+			fmt.Fprintf(&sb, "        %-24s %s\n", b.label, b.desc)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// keyProfiles are named sets of remappings selected with "key_profile" in
+// config.json. Entries in "keys" are applied on top of the profile.
+//
+// "less" follows less(1): j/k scroll a line, Space or f moves forward a page
+// and b back. "Back to file list" moves to B and the fit mode to W to make
+// room; /, n, N and q already match.
+var keyProfiles = map[string]map[string]string{
+	"less": {
+		"next_page":   "f",
+		"prev_page":   "b",
+		"scroll_down": "j",
+		"scroll_up":   "k",
+		"back":        "B",
+		"fit_mode":    "W",
+	},
+}
+
+// keyMap translates remapped keys back to the built-in key handleInput
+// switches on. A default key whose action was moved elsewhere maps to
+// KeyUnknown so it stops working, unless another action was moved onto it.
+type keyMap struct {
+	remap   map[terminal.Key]terminal.Key
+	current map[string]terminal.Key
+	profile string // name of the key profile in effect, "" for the defaults
+}
+
+// newKeyMap builds a keyMap from the named profile (see keyProfiles; unknown
+// names are ignored) and the config's action -> key entries. Values are a
+// single printable ASCII character or "space"; anything else is ignored, as
+// are digits, which start a count.
+func newKeyMap(profile string, custom map[string]string) keyMap {
+	km := keyMap{remap: map[terminal.Key]terminal.Key{}, current: map[string]terminal.Key{}}
+	if base, ok := keyProfiles[profile]; ok {
+		km.profile = profile
+		merged := make(map[string]string, len(base)+len(custom))
+		for action, key := range base {
+			merged[action] = key
+		}
+		for action, key := range custom {
+			merged[action] = key
+		}
+		custom = merged
+	}
+	var moved []terminal.Key
+	for _, sec := range keyBindings {
+		for _, b := range sec.rows {
+			if b.action == "" {
+				continue
+			}
+			k, ok := parseKeyName(custom[b.action])
+			if !ok || k == b.key {
+				continue
+			}
+			km.remap[k] = b.key
+			km.current[b.action] = k
+			moved = append(moved, b.key)
+		}
+	}
+	for _, k := range moved {
+		if _, taken := km.remap[k]; !taken {
+			km.remap[k] = terminal.KeyUnknown
+		}
+	}
+	return km
+}
+
+func parseKeyName(s string) (terminal.Key, bool) {
+	if strings.EqualFold(s, "space") {
+		return ' ', true
+	}
+	// The terminal reads keys a byte at a time, so only printable ASCII
+	// can be bound.
+	if len(s) != 1 || s[0] < ' ' || s[0] > '~' || s[0] >= '0' && s[0] <= '9' {
+		return 0, false
+	}
+	return terminal.Key(s[0]), true
+}
+
+func (km keyMap) translate(c terminal.Key) terminal.Key {
+	if k, ok := km.remap[c]; ok {
+		return k
+	}
+	return c
+}
+
+// label returns b's help label with the effective key in place of the default.
+func (km keyMap) label(b binding) string {
+	k, ok := km.current[b.action]
+	if !ok {
+		return b.label
+	}
+	name := string(rune(k))
+	if k == ' ' {
+		name = "Space"
+	}
+	return name + b.label[utf8.RuneLen(rune(b.key)):]
+}
@@ -0,0 +1,56 @@
+package viewer
+
+import (
+	"testing"
+
+	"pdf-cli/internal/terminal"
+)
+
+func TestParseKeyName(t *testing.T) {
+	tests := []struct {
+		name string
+		want terminal.Key
+		ok   bool
+	}{
+		{"x", 'x', true},
+		{"space", ' ', true},
+		{"SPACE", ' ', true},
+		{"~", '~', true},
+		{"ö", 0, false},
+		{"é", 0, false},
+		{"2", 0, false},
+		{"0", 0, false},
+		{"ab", 0, false},
+		{"", 0, false},
+		{"\t", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseKeyName(tt.name)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseKeyName(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestKeyMapKeepsDigitsForCounts(t *testing.T) {
+	km := newKeyMap("", map[string]string{"dual_page": "2"})
+	if got := km.translate('2'); got != '2' {
+		t.Errorf("translate('2') = %q, want '2' for counts", got)
+	}
+	if got := km.translate('v'); got != 'v' {
+		t.Errorf("translate('v') = %q, want 'v' still bound to dual_page", got)
+	}
+}
+
+func TestPanKeysIgnoreProfile(t *testing.T) {
+	d := &DocumentViewer{keys: newKeyMap("less", nil), zoom: 2, onImagePage: true, panX: 0.5, panY: 0.5}
+	d.handleInput('j')
+	if d.panY <= 0.5 {
+		t.Errorf("j under the less profile: panY = %v, want it moved down", d.panY)
+	}
+	d.handleInput('k')
+	d.handleInput('k')
+	if d.panY >= 0.5 {
+		t.Errorf("k under the less profile: panY = %v, want it moved up", d.panY)
+	}
+}
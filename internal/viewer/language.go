@@ -0,0 +1,136 @@
+package viewer
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"path"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// documentLanguage returns the language the document declares, as a BCP 47
+// tag such as "en-US" or "de": the catalog's /Lang entry for PDF, the OPF
+// package's dc:language for EPUB. MuPDF doesn't expose either, so they are
+// read directly. It returns "" when there is none.
+func documentLanguage(filePath, fileType, password string) string {
+	switch fileType {
+	case "pdf":
+		return pdfLanguage(filePath, password)
+	case "epub":
+		return epubLanguage(filePath)
+	}
+	return ""
+}
+
+func pdfLanguage(filePath, password string) (lang string) {
+	f, size, err := openSource(filePath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	// The reader panics on some malformed objects.
+	defer func() {
+		if recover() != nil {
+			lang = ""
+		}
+	}()
+	tried := false
+	r, err := pdf.NewReaderEncrypted(f, size, func() string {
+		if tried {
+			return ""
+		}
+		tried = true
+		return password
+	})
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(r.Trailer().Key("Root").Key("Lang").Text())
+}
+
+func epubLanguage(filePath string) string {
+	f, size, err := openSource(filePath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return ""
+	}
+
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if readZipXML(zr, "META-INF/container.xml", &container) != nil || len(container.Rootfiles) == 0 {
+		return ""
+	}
+	var pkg struct {
+		Languages []string `xml:"metadata>language"`
+	}
+	if readZipXML(zr, path.Clean(container.Rootfiles[0].FullPath), &pkg) != nil {
+		return ""
+	}
+	for _, l := range pkg.Languages {
+		if l = strings.TrimSpace(l); l != "" {
+			return l
+		}
+	}
+	return ""
+}
+
+// readZipXML decodes the XML file name in zr into v.
+func readZipXML(zr *zip.Reader, name string, v any) error {
+	rc, err := zr.Open(name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// primaryLanguage reduces a language tag to its lowercase primary subtag,
+// "pt-BR" to "pt".
+func primaryLanguage(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return tag
+}
+
+// languageEntities extends cleanEpubText's entity table for languages whose
+// quotation marks don't fold to ASCII. Without it guillemets are left as
+// entities, and German „low quotes“ come out as an entity followed by a
+// plain '"'. Unknown languages, and English, keep the ASCII table.
+var languageEntities = map[string]map[string]string{
+	"de": lowQuotes,
+	"cs": lowQuotes,
+	"pl": {"&#8222;": "„", "&bdquo;": "„", "&#8221;": "”", "&rdquo;": "”", "&laquo;": "«", "&#171;": "«", "&raquo;": "»", "&#187;": "»"},
+	"fr": guillemets,
+	"es": guillemets,
+	"it": guillemets,
+	"pt": guillemets,
+	"ru": guillemets,
+	"uk": guillemets,
+}
+
+var lowQuotes = map[string]string{
+	"&#8222;": "„", "&bdquo;": "„",
+	"&#8220;": "“", "&ldquo;": "“",
+	"&#8218;": "‚", "&sbquo;": "‚",
+	"&#8216;": "‘", "&lsquo;": "‘",
+	"&laquo;": "«", "&#171;": "«",
+	"&raquo;": "»", "&#187;": "»",
+}
+
+var guillemets = map[string]string{
+	"&laquo;": "«", "&#171;": "«",
+	"&raquo;": "»", "&#187;": "»",
+	"&#8249;": "‹", "&lsaquo;": "‹",
+	"&#8250;": "›", "&rsaquo;": "›",
+	"&#8239;": " ", // narrow no-break space, set inside French guillemets
+}
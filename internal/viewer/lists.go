@@ -0,0 +1,92 @@
+package viewer
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// bullets are the markers that start a bulleted list item, when followed by
+// a space.
+var bullets = []string{"•", "◦", "▪", "‣", "–", "-", "*"}
+
+// listMarker splits a list item into its marker, such as "•", "3." or
+// "2.1)", and the text after it. ok is false for lines that aren't list
+// items. Numbers are held to three digits a level, so that a wrapped line
+// starting with a year that ends a sentence isn't taken for one.
+func listMarker(line string) (marker, rest string, ok bool) {
+	line = strings.TrimLeft(line, " \t")
+	for _, b := range bullets {
+		if after, found := strings.CutPrefix(line, b+" "); found {
+			return b, strings.TrimSpace(after), true
+		}
+	}
+	i, digits := 0, 0
+	for i < len(line) {
+		c := line[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+			if digits > 3 {
+				return "", "", false
+			}
+		case (c == '.' || c == ')') && digits > 0:
+			if i+1 < len(line) && line[i+1] == ' ' {
+				return line[:i+1], strings.TrimSpace(line[i+1:]), true
+			}
+			if c == ')' || i+1 >= len(line) || line[i+1] < '0' || line[i+1] > '9' {
+				return "", "", false
+			}
+			digits = 0 // "2.1." goes on to the next level
+		default:
+			return "", "", false
+		}
+		i++
+	}
+	return "", "", false
+}
+
+// listIndent is the nesting indent of a list item: its leading whitespace,
+// tabs counted as four columns, capped so deep nesting still leaves room.
+func listIndent(line string) string {
+	n := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			n++
+		case '\t':
+			n += 4
+		default:
+			return strings.Repeat(" ", min(n, 12))
+		}
+	}
+	return ""
+}
+
+// splitListItems splits a paragraph's lines into blocks: each list item
+// with the lines that continue it, and any text before the first item.
+func splitListItems(lines []string) [][]string {
+	var blocks [][]string
+	for _, line := range lines {
+		if _, _, ok := listMarker(line); ok || len(blocks) == 0 {
+			blocks = append(blocks, nil)
+		}
+		blocks[len(blocks)-1] = append(blocks[len(blocks)-1], line)
+	}
+	return blocks
+}
+
+// wrapListItem wraps a list item to width with a hanging indent, so lines
+// after the first line up under its text rather than its marker.
+func (d *DocumentViewer) wrapListItem(indent, marker, text string, width int) []string {
+	hang := indent + strings.Repeat(" ", runewidth.StringWidth(marker)+1)
+	lines := d.wrapText(text, width-runewidth.StringWidth(hang))
+	for i := range lines {
+		if i == 0 {
+			lines[i] = indent + marker + " " + lines[i]
+		} else {
+			lines[i] = hang + lines[i]
+		}
+	}
+	return lines
+}
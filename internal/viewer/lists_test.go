@@ -0,0 +1,86 @@
+package viewer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestListMarker(t *testing.T) {
+	tests := []struct {
+		line, marker, rest string
+		ok                 bool
+	}{
+		{"• First point", "•", "First point", true},
+		{"    ◦ nested point", "◦", "nested point", true},
+		{"\t- dash item", "-", "dash item", true},
+		{"* star item", "*", "star item", true},
+		{"3. Third step", "3.", "Third step", true},
+		{"  2.1) Sub-step", "2.1)", "Sub-step", true},
+		{"  2.1.4. Deeper", "2.1.4.", "Deeper", true},
+		{"12) Twelfth", "12)", "Twelfth", true},
+		{"1999. was the year it ended", "", "", false},
+		{"3.14 is roughly pi", "", "", false},
+		{"2) ", "2)", "", true},
+		{"-dash without space", "", "", false},
+		{"Plain sentence.", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		marker, rest, ok := listMarker(tt.line)
+		if marker != tt.marker || rest != tt.rest || ok != tt.ok {
+			t.Errorf("listMarker(%q) = %q, %q, %v, want %q, %q, %v",
+				tt.line, marker, rest, ok, tt.marker, tt.rest, tt.ok)
+		}
+	}
+}
+
+func TestSplitListItems(t *testing.T) {
+	lines := []string{
+		"Before you start:",
+		"1. Install the tools",
+		"   from the website.",
+		"   • on Linux, use the",
+		"     package manager",
+		"   • on macOS, Homebrew",
+		"2. Run the setup",
+		"- then check the log",
+	}
+	want := [][]string{
+		{"Before you start:"},
+		{"1. Install the tools", "   from the website."},
+		{"   • on Linux, use the", "     package manager"},
+		{"   • on macOS, Homebrew"},
+		{"2. Run the setup"},
+		{"- then check the log"},
+	}
+	got := splitListItems(lines)
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("splitListItems() = %q, want %q", got, want)
+	}
+}
+
+func TestWrapListItem(t *testing.T) {
+	d := &DocumentViewer{}
+	tests := []struct {
+		indent, marker, text string
+		width                int
+		want                 []string
+	}{
+		{"", "•", "short item", 40, []string{"• short item"}},
+		{"", "10.", "the numbered text wraps under its first word", 30, []string{
+			"10. the numbered text wraps",
+			"    under its first word",
+		}},
+		{"    ", "◦", "a nested bullet keeps its indent on every line", 32, []string{
+			"    ◦ a nested bullet keeps its",
+			"      indent on every line",
+		}},
+	}
+	for _, tt := range tests {
+		got := d.wrapListItem(tt.indent, tt.marker, tt.text, tt.width)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("wrapListItem(%q, %q, %q, %d) = %q, want %q",
+				tt.indent, tt.marker, tt.text, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package viewer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// errNoConverter is returned for Kindle books when Calibre isn't installed.
+var errNoConverter = errors.New("MOBI and AZW3 books need Calibre's ebook-convert, which wasn't found")
+
+// isKindle reports whether fileType is a Kindle format, which MuPDF can't
+// read and is converted to EPUB instead.
+func isKindle(fileType string) bool {
+	return fileType == "mobi" || fileType == "azw3"
+}
+
+// ebookConvert finds Calibre's ebook-convert: on the PATH, or inside the
+// app bundle on macOS, where it usually isn't linked onto the PATH.
+func ebookConvert() (string, error) {
+	if path, err := exec.LookPath("ebook-convert"); err == nil {
+		return path, nil
+	}
+	if runtime.GOOS == "darwin" {
+		path := "/Applications/calibre.app/Contents/MacOS/ebook-convert"
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", errNoConverter
+}
+
+// convertKindle converts the Kindle book at path to an EPUB in dir and
+// returns the EPUB's path.
+func convertKindle(path, dir string) (string, error) {
+	exe, err := ebookConvert()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	out := filepath.Join(dir, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))+".epub")
+	if msg, err := exec.Command(exe, path, out).CombinedOutput(); err != nil {
+		// ebook-convert logs its progress; the reason is at the end.
+		lines := bytes.Split(bytes.TrimSpace(msg), []byte("\n"))
+		return "", fmt.Errorf("ebook-convert failed: %s", lines[len(lines)-1])
+	}
+	return out, nil
+}
@@ -0,0 +1,118 @@
+//go:build !cgo
+
+package viewer
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// openMuPDF stands in for the MuPDF backend in builds without cgo: PDFs are
+// opened text-only with a pure-Go reader and other formats are refused.
+func openMuPDF(path, fileType string, password func(attempt int) (string, bool)) (DocumentBackend, error) {
+	if fileType != "pdf" {
+		return nil, fmt.Errorf("%s files need MuPDF, which this build (without cgo) lacks", strings.ToUpper(fileType))
+	}
+	f, size, err := openSource(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// The reader keeps asking for passwords until it gets an empty one.
+	attempt := 0
+	var pw func() string
+	if password != nil {
+		pw = func() string {
+			attempt++
+			if attempt > maxPasswordAttempts {
+				return ""
+			}
+			p, ok := password(attempt)
+			if !ok {
+				return ""
+			}
+			return p
+		}
+	}
+	r, err := pdf.NewReaderEncrypted(f, size, pw)
+	if err == pdf.ErrInvalidPassword {
+		f.Close()
+		if attempt == 0 {
+			return nil, errEncrypted
+		}
+		return nil, errWrongPassword
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &pdfTextBackend{f: f, r: r}, nil
+}
+
+// pdfTextBackend reads PDF text without MuPDF. It has no rendered pages, so
+// the viewer shows every page as text.
+type pdfTextBackend struct {
+	f source
+	r *pdf.Reader
+}
+
+func (p *pdfTextBackend) NumPage() int { return p.r.NumPage() }
+
+// Text rebuilds the page's lines from its positioned glyphs, in content
+// stream order. Glyphs carry no spacing of their own, so a horizontal gap
+// wider than a fraction of the font size stands for a space and a change of
+// baseline starts a new line. Form field values follow the page text.
+func (p *pdfTextBackend) Text(n int) (text string, err error) {
+	if n < 0 || n >= p.r.NumPage() {
+		return "", fmt.Errorf("page %d out of range", n)
+	}
+	// The reader panics on some malformed content streams.
+	defer func() {
+		if x := recover(); x != nil {
+			text, err = "", fmt.Errorf("page %d: %v", n+1, x)
+		}
+	}()
+	page := p.r.Page(n + 1)
+	if page.V.IsNull() {
+		return "", nil
+	}
+	glyphs := page.Content().Text
+	newLine := func(a, b pdf.Text) bool { return math.Abs(b.Y-a.Y) > max(b.FontSize, 1)/2 }
+	var b strings.Builder
+	for i, t := range glyphs {
+		// TeX fonts end each line with a zero-width control glyph.
+		if t.W == 0 && (i == len(glyphs)-1 || newLine(t, glyphs[i+1])) {
+			continue
+		}
+		if b.Len() > 0 {
+			prev := glyphs[i-1]
+			if newLine(prev, t) {
+				b.WriteByte('\n')
+			} else if t.X-(prev.X+prev.W) > 0.15*max(t.FontSize, 1) {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(t.S)
+	}
+	if form := formText(p.r, n); form != "" {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(form)
+	}
+	return b.String(), nil
+}
+
+func (p *pdfTextBackend) Image(n int) (image.Image, error) { return nil, errNoImage }
+
+func (p *pdfTextBackend) ImageDPI(n int, dpi float64) (image.Image, error) { return nil, errNoImage }
+
+func (p *pdfTextBackend) Bound(n int) (image.Rectangle, error) { return image.Rectangle{}, errNoImage }
+
+func (p *pdfTextBackend) ToC() ([]Chapter, error) { return nil, nil }
+
+func (p *pdfTextBackend) Close() error { return p.f.Close() }
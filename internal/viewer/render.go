@@ -6,25 +6,36 @@ import (
 	"image/color"
 	"image/draw"
 	"image/png"
+	"math"
 	"os"
 	"path/filepath"
 
 	"github.com/blacktop/go-termimg"
+	xdraw "golang.org/x/image/draw"
 
 	"pdf-cli/internal/imgutil"
 )
 
 func (d *DocumentViewer) renderPageImage(pageNum, maxWidth, maxHeight int) int {
-	return d.renderPageImageAligned(pageNum, maxWidth, maxHeight, "center")
+	return d.renderPageImageAligned(pageNum, maxWidth, maxHeight, "center", false)
 }
 
-func (d *DocumentViewer) renderPageImageAligned(pageNum, maxWidth, maxHeight int, align string) int {
+// renderScrollablePageImage is renderPageImage for a page shown on its own:
+// in fit-width mode a page taller than the screen is shown a window at a
+// time, scrolled with lineOffset like a long text page, and the page can be
+// zoomed into and panned.
+func (d *DocumentViewer) renderScrollablePageImage(pageNum, maxWidth, maxHeight int) int {
+	return d.renderPageImageAligned(pageNum, maxWidth, maxHeight, "center", true)
+}
+
+func (d *DocumentViewer) renderPageImageAligned(pageNum, maxWidth, maxHeight int, align string, scroll bool) int {
 	if maxHeight <= 0 {
 		return 0
 	}
 
 	termType := d.detectTerminalType()
-	imagePath, actualHeight, imageWidthInChars, actualPixelWidth, actualPixelHeight, err := d.savePageAsImage(pageNum, maxWidth, maxHeight, termType)
+	imagePath, actualHeight, imageWidthInChars, actualPixelWidth, actualPixelHeight, err := d.savePageAsImage(pageNum, maxWidth, maxHeight, termType, scroll)
+	d.renderErr = err
 	if err != nil {
 		return 0
 	}
@@ -46,7 +57,45 @@ func (d *DocumentViewer) renderPageImageAligned(pageNum, maxWidth, maxHeight int
 	return d.renderWithTermImg(imagePath, actualHeight, horizontalOffset, imageWidthInChars, actualPixelWidth, actualPixelHeight, termType)
 }
 
-func (d *DocumentViewer) savePageAsImage(pageNum, termWidth, termHeight int, termType string) (string, int, int, int, int, error) {
+// defaultMaxDPI is the render DPI ceiling used when the user hasn't set one.
+func defaultMaxDPI(termType string) float64 {
+	if termType == "kitty" {
+		return 300.0
+	}
+	return 100.0
+}
+
+// effectiveMaxDPI returns the user's DPI ceiling, or the terminal default.
+func (d *DocumentViewer) effectiveMaxDPI(termType string) float64 {
+	if d.maxDPI != 0 {
+		return d.maxDPI
+	}
+	return defaultMaxDPI(termType)
+}
+
+// clampDPI bounds a computed render DPI to [36, max DPI].
+func (d *DocumentViewer) clampDPI(dpi float64, termType string) float64 {
+	if dpi < 36 {
+		dpi = 36
+	}
+	if maxDPI := d.effectiveMaxDPI(termType); dpi > maxDPI {
+		dpi = maxDPI
+	}
+	return dpi
+}
+
+// adjustMaxDPI steps the render DPI ceiling by delta within 72–400.
+func (d *DocumentViewer) adjustMaxDPI(delta float64) {
+	d.maxDPI = d.effectiveMaxDPI(d.detectTerminalType()) + delta
+	if d.maxDPI < 72 {
+		d.maxDPI = 72
+	}
+	if d.maxDPI > 400 {
+		d.maxDPI = 400
+	}
+}
+
+func (d *DocumentViewer) savePageAsImage(pageNum, termWidth, termHeight int, termType string, scroll bool) (string, int, int, int, int, error) {
 	if err := os.MkdirAll(d.tempDir, 0o755); err != nil {
 		return "", 0, 0, 0, 0, err
 	}
@@ -103,31 +152,32 @@ func (d *DocumentViewer) savePageAsImage(pageNum, termWidth, termHeight int, ter
 		dpi = dpiForHeight
 	}
 
-	if dpi < 36 {
-		dpi = 36
+	dpi = d.clampDPI(dpi, termType)
+
+	// A zoomed page is rendered larger and cut down to the screen, so the
+	// detail is real rather than upscaled, up to maxZoomDPI.
+	if scroll {
+		d.onImagePage = true
 	}
-	maxDPI := 300.0
-	if termType != "kitty" {
-		maxDPI = 100.0
-	}
-	if dpi > maxDPI {
-		dpi = maxDPI
+	zoomed := scroll && d.zoom > 1
+	renderDPI := dpi
+	if zoomed {
+		renderDPI = min(dpi*d.zoom, max(dpi, maxZoomDPI))
 	}
 
-	img, err := d.doc.ImageDPI(pageNum, dpi)
+	img, err := d.doc.ImageDPI(pageNum, renderDPI)
 	if err != nil {
 		return "", 0, 0, 0, 0, err
 	}
 
-	var finalImg image.Image = img
-	switch d.darkMode {
-	case "smart":
-		finalImg = imgutil.SmartInvert(img)
-	case "invert":
-		finalImg = imgutil.SimpleInvert(img)
-	}
-
+	finalImg := d.adjustColors(img)
 	finalImg = imgutil.CropImage(finalImg, d.cropTop, d.cropBottom, d.cropLeft, d.cropRight)
+	switch {
+	case zoomed:
+		finalImg = d.zoomViewport(finalImg, targetPixelWidth, targetPixelHeight, dpi*d.zoom/renderDPI)
+	case scroll && d.fitMode == "width":
+		finalImg = d.scrollWindow(finalImg, termHeight, pixelsPerLine)
+	}
 
 	bounds := finalImg.Bounds()
 	actualWidth := bounds.Dx()
@@ -158,6 +208,61 @@ func (d *DocumentViewer) savePageAsImage(pageNum, termWidth, termHeight int, ter
 	return imagePath, actualLines, imageWidthInChars, actualWidth, actualHeight, nil
 }
 
+// scrollWindow cuts the rows of img visible at the current lineOffset when
+// img is taller than lines terminal rows, and records the page's height in
+// rows so scrolling and the status line work as for text pages.
+func (d *DocumentViewer) scrollWindow(img image.Image, lines int, pixelsPerLine float64) image.Image {
+	b := img.Bounds()
+	total := int(math.Ceil(float64(b.Dy()) / pixelsPerLine))
+	if total <= lines {
+		return img
+	}
+	d.pageLines, d.visibleLines, d.imageScroll = total, lines, true
+	d.lineOffset = min(max(d.lineOffset, 0), total-lines)
+
+	top := b.Min.Y + int(float64(d.lineOffset)*pixelsPerLine)
+	bottom := min(top+int(float64(lines)*pixelsPerLine), b.Max.Y)
+	window := image.NewRGBA(image.Rect(0, 0, b.Dx(), bottom-top))
+	draw.Draw(window, window.Bounds(), img, image.Pt(b.Min.X, top), draw.Src)
+	return window
+}
+
+// maxZoomDPI caps the render resolution of a zoomed page; a full page at
+// higher DPIs takes too much memory. Zooming further upscales.
+const maxZoomDPI = 400.0
+
+// zoomViewport cuts a w×h pixel region around the pan position out of the
+// zoomed page, moving the pan back onto the page if needed. upscale is the
+// part of the zoom the render DPI couldn't provide; the region is taken that
+// much smaller and scaled up to w×h.
+func (d *DocumentViewer) zoomViewport(img image.Image, w, h int, upscale float64) image.Image {
+	b := img.Bounds()
+	vw := min(int(float64(w)/upscale), b.Dx())
+	vh := min(int(float64(h)/upscale), b.Dy())
+	if vw <= 0 || vh <= 0 {
+		return img
+	}
+	d.panX = clampPan(d.panX, float64(vw)/float64(b.Dx()))
+	d.panY = clampPan(d.panY, float64(vh)/float64(b.Dy()))
+	x := min(max(b.Min.X+int(d.panX*float64(b.Dx()))-vw/2, b.Min.X), b.Max.X-vw)
+	y := min(max(b.Min.Y+int(d.panY*float64(b.Dy()))-vh/2, b.Min.Y), b.Max.Y-vh)
+	src := image.Rect(x, y, x+vw, y+vh)
+
+	dst := image.NewRGBA(image.Rect(0, 0, int(float64(vw)*upscale), int(float64(vh)*upscale)))
+	if dst.Rect.Eq(image.Rect(0, 0, vw, vh)) {
+		draw.Draw(dst, dst.Bounds(), img, src.Min, draw.Src)
+	} else {
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, src, xdraw.Src, nil)
+	}
+	return dst
+}
+
+// clampPan keeps a viewport center, as a fraction of the page, at least half
+// the viewport's span from either edge.
+func clampPan(center, span float64) float64 {
+	return min(max(center, span/2), 1-span/2)
+}
+
 func (d *DocumentViewer) renderPageToImage(pageNum, termWidth, termHeight int, termType string) (image.Image, error) {
 	pixelsPerChar, pixelsPerLine := d.getTerminalCellSize()
 
@@ -211,29 +316,31 @@ func (d *DocumentViewer) renderPageToImage(pageNum, termWidth, termHeight int, t
 		dpi = dpiForHeight
 	}
 
-	if dpi < 36 {
-		dpi = 36
-	}
-	maxDPI := 300.0
-	if termType != "kitty" {
-		maxDPI = 100.0
-	}
-	if dpi > maxDPI {
-		dpi = maxDPI
-	}
+	dpi = d.clampDPI(dpi, termType)
 
 	img, err := d.doc.ImageDPI(pageNum, dpi)
 	if err != nil {
 		return nil, err
 	}
+	return d.adjustColors(img), nil
+}
 
+// adjustColors applies the tone settings and then dark mode to a rendered
+// page.
+func (d *DocumentViewer) adjustColors(img image.Image) image.Image {
+	img = imgutil.AdjustTone(img, d.brightness, d.contrast, d.grayscale)
 	switch d.darkMode {
 	case "smart":
-		return imgutil.SmartInvert(img), nil
+		return imgutil.SmartInvert(img)
 	case "invert":
-		return imgutil.SimpleInvert(img), nil
+		return imgutil.SimpleInvert(img)
 	}
-	return img, nil
+	return img
+}
+
+// adjustTone steps brightness or contrast by delta within their ranges.
+func (d *DocumentViewer) adjustTone(value *float64, delta, lo, hi float64) {
+	*value = math.Round(max(lo, min(hi, *value+delta))*10) / 10
 }
 
 func (d *DocumentViewer) renderDualComposite(page1, page2 int, hasPage2 bool, termWidth, termHeight int, layout string, gap int) int {
@@ -389,29 +496,14 @@ func (d *DocumentViewer) renderHalfPage(pageNum, termWidth, termHeight int, isBo
 	}
 	dpi := targetFullPixels / float64(pageHeightAt72) * 72.0 * scale
 
-	if dpi < 36 {
-		dpi = 36
-	}
-	maxDPI := 300.0
-	if termType != "kitty" {
-		maxDPI = 100.0
-	}
-	if dpi > maxDPI {
-		dpi = maxDPI
-	}
+	dpi = d.clampDPI(dpi, termType)
 
 	rawImg, err := d.doc.ImageDPI(pageNum, dpi)
 	if err != nil {
 		return 0
 	}
 
-	var img image.Image = rawImg
-	switch d.darkMode {
-	case "smart":
-		img = imgutil.SmartInvert(rawImg)
-	case "invert":
-		img = imgutil.SimpleInvert(rawImg)
-	}
+	img := d.adjustColors(rawImg)
 
 	bounds := img.Bounds()
 	fullH := bounds.Dy()
@@ -478,6 +570,10 @@ func (d *DocumentViewer) renderHalfPage(pageNum, termWidth, termHeight int, isBo
 }
 
 func (d *DocumentViewer) renderWithTermImg(imagePath string, estimatedLines int, horizontalOffset int, widthChars int, pixelWidth int, pixelHeight int, termType string) int {
+	if d.useBlockArt(termType) {
+		return d.renderBlockArt(imagePath, estimatedLines, horizontalOffset, widthChars)
+	}
+
 	if horizontalOffset > 0 {
 		fmt.Printf("\033[%dC", horizontalOffset)
 	}
@@ -487,6 +583,9 @@ func (d *DocumentViewer) renderWithTermImg(imagePath string, estimatedLines int,
 		return 0
 	}
 
+	if d.protocol != termimg.Unsupported {
+		img = img.Protocol(d.protocol)
+	}
 	if termType == "kitty" {
 		err = img.Width(widthChars).Height(estimatedLines).Scale(termimg.ScaleNone).Print()
 	} else {
@@ -494,8 +593,63 @@ func (d *DocumentViewer) renderWithTermImg(imagePath string, estimatedLines int,
 	}
 
 	if err != nil {
-		return 0
+		// Don't retry the graphics protocol on every page once it has failed.
+		d.blockArt = true
+		fmt.Print("\r")
+		return d.renderBlockArt(imagePath, estimatedLines, horizontalOffset, widthChars)
 	}
 
 	return estimatedLines
 }
+
+// useBlockArt reports whether images should be drawn as ANSI half-blocks
+// because the terminal has no graphics protocol. Detected once per viewer.
+//
+// Inside tmux only protocols that survive it are used: iTerm2 images, which
+// termimg wraps in tmux passthrough, and sixel, which tmux 3.4+ draws itself.
+// Kitty graphics need tmux's cooperation and fail there.
+func (d *DocumentViewer) useBlockArt(termType string) bool {
+	if !d.graphicsProbed {
+		d.graphicsProbed = true
+		switch termType {
+		case "kitty", "iterm2", "wezterm", "foot":
+			d.blockArt = false
+		case "tmux":
+			switch {
+			case termimg.ITerm2Supported():
+				d.protocol = termimg.ITerm2
+			case termimg.SixelSupported():
+				d.protocol = termimg.Sixel
+			}
+			d.blockArt = d.protocol == termimg.Unsupported
+		default:
+			d.blockArt = !termimg.KittySupported() && !termimg.SixelSupported() && !termimg.ITerm2Supported()
+		}
+	}
+	return d.blockArt
+}
+
+// renderBlockArt draws the image at imagePath as colored half-block characters.
+func (d *DocumentViewer) renderBlockArt(imagePath string, lines, horizontalOffset, widthChars int) int {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return 0
+	}
+	img, err := png.Decode(file)
+	file.Close()
+	if err != nil {
+		return 0
+	}
+
+	rows := imgutil.HalfBlocks(img, widthChars, lines)
+	for i, row := range rows {
+		if i > 0 {
+			fmt.Print("\r\n")
+		}
+		if horizontalOffset > 0 {
+			fmt.Printf("\033[%dC", horizontalOffset)
+		}
+		fmt.Print(row)
+	}
+	return len(rows)
+}
@@ -0,0 +1,82 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+
+	"pdf-cli/internal/terminal"
+)
+
+// selectLines lets the user pick a run of lines on the displayed text page
+// and copies them to the clipboard. The selection starts on the ruler's
+// line, or the top line on screen; j/k move its far end, o swaps which end
+// moves, Enter or y copies and Esc or q cancels.
+func (d *DocumentViewer) selectLines(inputChan <-chan terminal.Key) {
+	if len(d.shownLines) == 0 {
+		d.showMessage(inputChan, "Lines can only be selected on text pages")
+		return
+	}
+	start := d.lineOffset
+	if d.rulerActive() {
+		start = d.rulerLine
+	}
+	d.selStart, d.selEnd = start, start
+	d.selecting = true
+	defer func() { d.selecting = false }()
+
+	for {
+		d.displayCurrentPage()
+		if len(d.shownLines) == 0 {
+			return // resized onto a page that isn't text
+		}
+		page := max(d.visibleLines-1, 1)
+		switch <-inputChan {
+		case 'j', terminal.KeyDown, terminal.KeyWheelDown:
+			d.selEnd++
+		case 'k', terminal.KeyUp, terminal.KeyWheelUp:
+			d.selEnd--
+		case 'J', ' ', terminal.KeyPageDown, terminal.KeyShiftDown:
+			d.selEnd += page
+		case 'K', terminal.KeyPageUp, terminal.KeyShiftUp:
+			d.selEnd -= page
+		case terminal.KeyHome:
+			d.selEnd = 0
+		case terminal.KeyEnd:
+			d.selEnd = len(d.shownLines) - 1
+		case 'o':
+			d.selStart, d.selEnd = d.selEnd, d.selStart
+		case 13, 10, 'y':
+			d.copySelection(inputChan)
+			return
+		case 27, 'q', 'V':
+			return
+		}
+		d.selEnd = max(min(d.selEnd, len(d.shownLines)-1), 0)
+	}
+}
+
+// selection returns the selected lines, first to last.
+func (d *DocumentViewer) selection() (first, last int) {
+	return min(d.selStart, d.selEnd), max(d.selStart, d.selEnd)
+}
+
+// copySelection copies the selected lines as shown, less the blank lines at
+// either end, and says how it went.
+func (d *DocumentViewer) copySelection(inputChan <-chan terminal.Key) {
+	first, last := d.selection()
+	lines := make([]string, 0, last-first+1)
+	for _, line := range d.shownLines[first : last+1] {
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	text := strings.Trim(strings.Join(lines, "\n"), "\n")
+	if text == "" {
+		d.showMessage(inputChan, "Nothing to copy: the selected lines are blank")
+		return
+	}
+	n := strings.Count(text, "\n") + 1
+	if copyText(text) {
+		d.showMessage(inputChan, fmt.Sprintf("Copied %d line(s) to the clipboard", n))
+	} else {
+		d.showMessage(inputChan, fmt.Sprintf("Sent %d line(s) to the terminal's clipboard (no pbcopy, wl-copy, xclip or xsel found)", n))
+	}
+}
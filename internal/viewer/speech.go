@@ -0,0 +1,112 @@
+package viewer
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"pdf-cli/internal/terminal"
+)
+
+// speaker is a text-to-speech program that reads text from stdin. stop,
+// if set, is run as well as killing the program, for speakers that hand
+// the text on to a daemon.
+type speaker struct {
+	name string
+	args []string
+	stop []string
+}
+
+// speakers are tried in order; the first one installed is used.
+var speakers = []speaker{
+	{name: "say", args: []string{"-f", "-"}},
+	{name: "espeak-ng", args: []string{"--stdin"}},
+	{name: "espeak", args: []string{"--stdin"}},
+	{name: "spd-say", args: []string{"--wait", "--pipe-mode"}, stop: []string{"--cancel"}},
+}
+
+// findSpeaker returns the first of speakers found on PATH. say is only
+// looked for on macOS.
+func findSpeaker() (speaker, bool) {
+	for _, s := range speakers {
+		if s.name == "say" && runtime.GOOS != "darwin" {
+			continue
+		}
+		if _, err := exec.LookPath(s.name); err == nil {
+			return s, true
+		}
+	}
+	return speaker{}, false
+}
+
+// toggleSpeech reads the current page aloud in the background, or stops
+// the reading if one is still going.
+func (d *DocumentViewer) toggleSpeech(inputChan <-chan terminal.Key) {
+	if d.stopSpeech() {
+		return
+	}
+	s, ok := findSpeaker()
+	if !ok {
+		d.showMessage(inputChan, "No text-to-speech program found; install espeak-ng or spd-say (say on macOS)")
+		return
+	}
+	pageNum := d.textPages[d.currentPage]
+	text, err := d.speechText(pageNum)
+	if err != nil || text == "" {
+		d.showMessage(inputChan, fmt.Sprintf("No text to read on page %d", pageNum+1))
+		return
+	}
+
+	cmd := exec.Command(s.name, s.args...)
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Start(); err != nil {
+		d.showMessage(inputChan, "Can't start "+s.name+": "+err.Error())
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+	d.speech, d.speechDone, d.speaker = cmd, done, s
+}
+
+// stopSpeech stops a reading that is still going, reporting whether there
+// was one.
+func (d *DocumentViewer) stopSpeech() bool {
+	if d.speech == nil {
+		return false
+	}
+	cmd, done := d.speech, d.speechDone
+	d.speech, d.speechDone = nil, nil
+	select {
+	case <-done:
+		return false
+	default:
+	}
+	cmd.Process.Kill()
+	<-done
+	if d.speaker.stop != nil {
+		exec.Command(d.speaker.name, d.speaker.stop...).Run()
+	}
+	return true
+}
+
+// speechText is the text of page pageNum as it should be read: with EPUB
+// markup stripped and hyphenation undone as for display, each paragraph on
+// one line and runs of spaces collapsed, so that line breaks from the page
+// layout don't become pauses.
+func (d *DocumentViewer) speechText(pageNum int) (string, error) {
+	text, err := d.pageText(pageNum)
+	if err != nil {
+		return "", err
+	}
+	var paragraphs []string
+	for _, line := range d.reflowText(text, 1<<20) {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			paragraphs = append(paragraphs, line)
+		}
+	}
+	return strings.Join(paragraphs, "\n"), nil
+}
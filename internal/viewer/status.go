@@ -0,0 +1,86 @@
+package viewer
+
+import "strings"
+
+// defaultStatusFormat is the status bar layout used when the status_format
+// setting is empty.
+const defaultStatusFormat = "{epub_chapter}Page {page}/{total}{doc_page} ({type}){flags} - {format}"
+
+// statusTokens are the names that can appear in braces in a status format:
+//
+//	page          the page number, counting only the pages shown
+//	total         the number of pages ("…" while still counting them)
+//	doc_page      the document's own page number, e.g. " [PDF p.52]", when skipped blank pages make it differ
+//	percent       how far through the document, 0-100
+//	chapter       the current chapter's title
+//	epub_chapter  "Ch. 3: Title — " in EPUBs, where page numbers mean little
+//	type          what the page shows: Text, Image or Mixed
+//	format        the document type: PDF, EPUB, ...
+//	filename      the document's file name
+//	time          the time of day, as 15:04
+//	flags         the bracketed indicators for modes, zoom, search and the rest
+var statusTokens = map[string]bool{
+	"page": true, "total": true, "doc_page": true, "percent": true,
+	"chapter": true, "epub_chapter": true, "type": true, "format": true,
+	"filename": true, "time": true, "flags": true,
+}
+
+// statusPart is a piece of a parsed status format: literal text, or a token
+// to fill in.
+type statusPart struct {
+	text  string
+	token bool
+}
+
+// parseStatusFormat splits format into literal text and tokens, once, so the
+// status bar needn't parse it on every redraw. Braces around anything but a
+// known token are left as they are.
+func parseStatusFormat(format string) []statusPart {
+	if format == "" {
+		format = defaultStatusFormat
+	}
+	var parts []statusPart
+	literal := ""
+	for format != "" {
+		open := strings.IndexByte(format, '{')
+		if open < 0 {
+			literal += format
+			break
+		}
+		end := strings.IndexByte(format[open:], '}')
+		if end < 0 {
+			literal += format
+			break
+		}
+		name := format[open+1 : open+end]
+		if !statusTokens[name] {
+			literal += format[:open+end+1]
+		} else {
+			literal += format[:open]
+			if literal != "" {
+				parts = append(parts, statusPart{text: literal})
+				literal = ""
+			}
+			parts = append(parts, statusPart{text: name, token: true})
+		}
+		format = format[open+end+1:]
+	}
+	if literal != "" {
+		parts = append(parts, statusPart{text: literal})
+	}
+	return parts
+}
+
+// formatStatus lays out the status bar from the parsed format, filling in
+// tokens from values.
+func formatStatus(parts []statusPart, values map[string]string) string {
+	var b strings.Builder
+	for _, p := range parts {
+		if p.token {
+			b.WriteString(values[p.text])
+		} else {
+			b.WriteString(p.text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,107 @@
+package viewer
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// StdinPath is the path that stands for a document piped to stdin, as in
+// "curl -s …/doc.pdf | pdf-cli -".
+const StdinPath = "-"
+
+// stdinData and stdinType hold the document read by ReadStdin.
+var (
+	stdinData []byte
+	stdinType string
+)
+
+// ReadStdin reads the whole of stdin as the document to open for StdinPath.
+// Its type is told from its first bytes, as there is no extension to go by.
+func ReadStdin() error {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("nothing piped to standard input")
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("reading standard input: %v", err)
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("standard input is empty")
+	}
+	fileType := sniffType(data)
+	if fileType == "" {
+		return fmt.Errorf("standard input isn't a PDF, EPUB or DOCX document")
+	}
+	stdinData, stdinType = data, fileType
+	return nil
+}
+
+// sniffType returns the file type of a document from its contents: "pdf",
+// "epub" or "docx", or "" for anything else.
+func sniffType(data []byte) string {
+	// PDF readers accept junk before the header, within the first 1K.
+	if bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-")) {
+		return "pdf"
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return ""
+	}
+	for _, f := range zr.File {
+		switch f.Name {
+		case "mimetype":
+			rc, err := f.Open()
+			if err != nil {
+				continue
+			}
+			mime, _ := io.ReadAll(io.LimitReader(rc, 64))
+			rc.Close()
+			if strings.TrimSpace(string(mime)) == "application/epub+zip" {
+				return "epub"
+			}
+		case "word/document.xml":
+			return "docx"
+		}
+	}
+	return ""
+}
+
+// source is a document's bytes, read from its file or from stdin.
+type source interface {
+	io.ReaderAt
+	io.Closer
+}
+
+type memSource struct{ *bytes.Reader }
+
+func (memSource) Close() error { return nil }
+
+// openSource opens path for the readers that need random access to the raw
+// file, returning it with its size.
+func openSource(path string) (source, int64, error) {
+	if path == StdinPath {
+		return memSource{bytes.NewReader(stdinData)}, int64(len(stdinData)), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+// piped reports whether the document was read from stdin, so has no file
+// to reload, remember settings for or hand to other apps.
+func (d *DocumentViewer) piped() bool {
+	return d.path == StdinPath
+}
@@ -0,0 +1,188 @@
+package viewer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// tableTexter is implemented by backends that can set the tables on a page
+// out as grids.
+type tableTexter interface {
+	TableText(n int) (string, error)
+}
+
+// minTableRows is the fewest rows of aligned cells taken for a table.
+const minTableRows = 3
+
+// glyphWidth is the rough width of an average glyph, as a fraction of the
+// line height, used to guess where a line of text ends: MuPDF's HTML only
+// gives where it starts.
+const glyphWidth = 0.45
+
+// numericCell matches cells to right-align: amounts, percentages, counts.
+var numericCell = regexp.MustCompile(`^[-+−(]?[$€£¥]?[\d.,]+%?\)?$`)
+
+// tableText orders a page's positioned lines like joinLines, but sets runs
+// of rows whose lines line up in columns as tables drawn with box
+// characters. MuPDF puts each table cell on a line of its own, so a row of
+// two or more lines on one baseline is a candidate table row; consecutive
+// ones become a table when their cells fall into common columns. This is a
+// guess, and two-column prose is ruled out by its long lines.
+func tableText(page string) string {
+	lines := htmlLines(page)
+	if len(lines) == 0 {
+		return ""
+	}
+	rows := tableRows(lines)
+
+	var parts []string
+	var plain []textLine
+	flush := func() {
+		if len(plain) > 0 {
+			parts = append(parts, joinLines(plain))
+			plain = nil
+		}
+	}
+	for i := 0; i < len(rows); {
+		j := i
+		for j < len(rows) && len(rows[j]) >= 2 && (j == i || rows[j][0].top-rows[j-1][0].top <= 3*max(rows[j-1][0].height, 1)) {
+			j++
+		}
+		if j-i >= minTableRows {
+			if cells, ok := tableCells(rows[i:j]); ok {
+				flush()
+				parts = append(parts, drawTable(cells))
+				i = j
+				continue
+			}
+		}
+		j = max(j, i+1)
+		for _, row := range rows[i:j] {
+			plain = append(plain, row...)
+		}
+		i = j
+	}
+	flush()
+	return strings.Join(parts, "\n\n")
+}
+
+// tableRows groups lines sharing a baseline into rows, top to bottom, each
+// sorted left to right.
+func tableRows(lines []textLine) [][]textLine {
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].top < lines[j].top })
+	var rows [][]textLine
+	for _, l := range lines {
+		if n := len(rows); n > 0 && l.top-rows[n-1][0].top <= 0.4*max(rows[n-1][0].height, 1) {
+			rows[n-1] = append(rows[n-1], l)
+			continue
+		}
+		rows = append(rows, []textLine{l})
+	}
+	for _, row := range rows {
+		sort.SliceStable(row, func(i, j int) bool { return row[i].left < row[j].left })
+	}
+	return rows
+}
+
+// tableCells sorts the lines of rows into columns: the spans of the page
+// covered by lines of some row, so that left-, right- and center-aligned
+// cells all land in the right one. ok is false when the rows don't look
+// like a table.
+func tableCells(rows [][]textLine) (cells [][]string, ok bool) {
+	type span struct{ lo, hi float64 }
+	var spans []span
+	chars, count := 0, 0
+	for _, row := range rows {
+		for _, l := range row {
+			w := runewidth.StringWidth(l.text)
+			spans = append(spans, span{l.left, l.left + float64(w)*glyphWidth*max(l.height, 1)})
+			chars += w
+			count++
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].lo < spans[j].lo })
+	var cols []span
+	for _, s := range spans {
+		if n := len(cols); n > 0 && s.lo <= cols[n-1].hi {
+			cols[n-1].hi = max(cols[n-1].hi, s.hi)
+			continue
+		}
+		cols = append(cols, s)
+	}
+	// Two columns of long lines are more likely a two-column page.
+	if len(cols) < 2 || len(cols) == 2 && chars > 30*count {
+		return nil, false
+	}
+
+	filled := 0
+	cells = make([][]string, len(rows))
+	for r, row := range rows {
+		cells[r] = make([]string, len(cols))
+		for _, l := range row {
+			c := sort.Search(len(cols), func(i int) bool { return cols[i].hi >= l.left }) // the span l lies in
+			if cells[r][c] == "" {
+				cells[r][c] = l.text
+				filled++
+			} else {
+				cells[r][c] += " " + l.text
+			}
+		}
+	}
+	if filled*5 < len(rows)*len(cols)*3 {
+		return nil, false
+	}
+	return cells, true
+}
+
+// drawTable sets cells out as a grid of box-drawing characters, with a
+// rule under the first row as it is usually a header. Numbers are aligned
+// right, everything else left.
+func drawTable(cells [][]string) string {
+	widths := make([]int, len(cells[0]))
+	for _, row := range cells {
+		for c, cell := range row {
+			widths[c] = max(widths[c], runewidth.StringWidth(cell))
+		}
+	}
+	rule := func(left, mid, right string) string {
+		parts := make([]string, len(widths))
+		for c, w := range widths {
+			parts[c] = strings.Repeat("─", w+2)
+		}
+		return left + strings.Join(parts, mid) + right
+	}
+
+	lines := []string{rule("┌", "┬", "┐")}
+	for r, row := range cells {
+		var b strings.Builder
+		b.WriteString("│")
+		for c, cell := range row {
+			pad := strings.Repeat(" ", widths[c]-runewidth.StringWidth(cell))
+			if numericCell.MatchString(cell) {
+				b.WriteString(" " + pad + cell + " │")
+			} else {
+				b.WriteString(" " + cell + pad + " │")
+			}
+		}
+		lines = append(lines, b.String())
+		if r == 0 && len(cells) > 1 {
+			lines = append(lines, rule("├", "┼", "┤"))
+		}
+	}
+	lines = append(lines, rule("└", "┴", "┘"))
+	return strings.Join(lines, "\n")
+}
+
+// isTableLine reports whether line is part of a table drawn by drawTable,
+// which reflowing must leave as it is.
+func isTableLine(line string) bool {
+	for _, prefix := range []string{"┌", "│", "├", "└"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
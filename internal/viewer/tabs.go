@@ -0,0 +1,111 @@
+package viewer
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/mattn/go-runewidth"
+
+	"pdf-cli/internal/terminal"
+)
+
+// tabSet is the documents open in one viewer session, each with its own
+// page, search and display state. Every viewer in it points to it.
+type tabSet struct {
+	docs    []*DocumentViewer
+	running atomic.Bool // Run is active, so background panics go to crashed
+	crashed chan any    // a panic from any tab's background goroutine, for Run to raise
+}
+
+// OpenTab opens path in a new tab after d's, for Run to switch to with Tab.
+func (d *DocumentViewer) OpenTab(path string) error {
+	t := NewDocumentViewer(path)
+	if err := t.Open(); err != nil {
+		return err
+	}
+	t.tabs = d.tabs
+	d.tabs.docs = append(d.tabs.docs, t)
+	return nil
+}
+
+// next returns the tab dir places after d, wrapping around.
+func (ts *tabSet) next(d *DocumentViewer, dir int) *DocumentViewer {
+	n := len(ts.docs)
+	i := slices.Index(ts.docs, d)
+	return ts.docs[((i+dir)%n+n)%n]
+}
+
+// closeAll saves the settings of the documents that were shown and closes
+// them all, when Run ends.
+func (ts *tabSet) closeAll() {
+	for _, t := range ts.docs {
+		t.stopSpeech()
+		if t.started {
+			t.saveConfig()
+		}
+		t.cleanup()
+		t.Close()
+	}
+}
+
+// openTab asks for a file to open in a new tab, starting from the current
+// document's directory, and returns the new tab, or nil if none was opened.
+func (d *DocumentViewer) openTab(inputChan <-chan terminal.Key) *DocumentViewer {
+	dir := "."
+	if !d.piped() {
+		dir = filepath.Dir(d.path)
+	}
+	path, ok := d.readLine(inputChan, "Open in new tab: ", dir+string(filepath.Separator))
+	path = strings.TrimSpace(path)
+	if !ok || path == "" {
+		return nil
+	}
+	path = expandHome(path)
+	if err := d.OpenTab(path); err != nil {
+		d.showMessage(inputChan, fmt.Sprintf("Can't open %s: %v", path, err))
+		return nil
+	}
+	return d.tabs.docs[len(d.tabs.docs)-1]
+}
+
+// tabBarHeight is the number of rows the tab bar takes: one when there is
+// more than one tab.
+func (d *DocumentViewer) tabBarHeight(termHeight int) int {
+	if len(d.tabs.docs) < 2 || termHeight < 6 {
+		return 0
+	}
+	return 1
+}
+
+// drawTabBar lists the open documents across the top row, the current one
+// in reverse video. When they don't all fit, those around the current one
+// are shown.
+func (d *DocumentViewer) drawTabBar(termWidth int) {
+	const minWidth = 12
+	cur := slices.Index(d.tabs.docs, d)
+	shown := min(len(d.tabs.docs), max(termWidth/minWidth, 1))
+	start := min(max(cur-shown/2, 0), len(d.tabs.docs)-shown)
+	width := termWidth / shown
+
+	var b strings.Builder
+	for i := start; i < start+shown; i++ {
+		t := d.tabs.docs[i]
+		name := filepath.Base(t.path)
+		if t.piped() {
+			name = "stdin"
+		}
+		label := runewidth.FillRight(runewidth.Truncate(fmt.Sprintf(" %d:%s", i+1, name), width-1, "…"), width-1)
+		if t == d {
+			b.WriteString("\033[7m" + label + "\033[27m")
+		} else {
+			b.WriteString("\033[2m" + label + "\033[22m")
+		}
+		if i < start+shown-1 {
+			b.WriteString("│")
+		}
+	}
+	fmt.Print("\033[1;1H\033[2K" + b.String() + "\033[0m")
+}
@@ -0,0 +1,112 @@
+package viewer
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var errNoImage = errors.New("page has no image")
+
+// textBackend serves a plain text or Markdown file as synthetic pages of
+// roughly one screen each.
+type textBackend struct {
+	pages []string
+}
+
+func newTextBackend(path string, markdown bool, linesPerPage int) (*textBackend, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if linesPerPage < 10 {
+		linesPerPage = 10
+	}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if markdown {
+		lines = stripMarkdown(lines)
+	}
+
+	doc := &textBackend{}
+	for len(lines) > 0 {
+		n := min(linesPerPage, len(lines))
+		// Prefer ending a page on a blank line in its last third so
+		// paragraphs aren't split across pages.
+		if n < len(lines) {
+			for i := n - 1; i >= linesPerPage*2/3; i-- {
+				if strings.TrimSpace(lines[i]) == "" {
+					n = i + 1
+					break
+				}
+			}
+		}
+		doc.pages = append(doc.pages, strings.Join(lines[:n], "\n"))
+		lines = lines[n:]
+	}
+	return doc, nil
+}
+
+func (t *textBackend) NumPage() int { return len(t.pages) }
+
+func (t *textBackend) Text(n int) (string, error) {
+	if n < 0 || n >= len(t.pages) {
+		return "", fmt.Errorf("page %d out of range", n)
+	}
+	return t.pages[n], nil
+}
+
+func (t *textBackend) Image(n int) (image.Image, error) { return nil, errNoImage }
+
+func (t *textBackend) ImageDPI(n int, dpi float64) (image.Image, error) { return nil, errNoImage }
+
+func (t *textBackend) Bound(n int) (image.Rectangle, error) { return image.Rectangle{}, errNoImage }
+
+func (t *textBackend) ToC() ([]Chapter, error) { return nil, nil }
+
+func (t *textBackend) Close() error { return nil }
+
+var (
+	mdHeading  = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+	mdStrong   = regexp.MustCompile(`(\*\*|__)(\S.*?)(\*\*|__)`)
+	mdEmphasis = regexp.MustCompile(`(^|[^\w*])\*(\S[^*]*?)\*`)
+	mdCode     = regexp.MustCompile("`([^`]+)`")
+	mdLink     = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+)
+
+// stripMarkdown removes Markdown markup that would otherwise clutter the
+// reflowed text. Headings are upper-cased and set off by blank lines.
+func stripMarkdown(lines []string) []string {
+	out := make([]string, 0, len(lines))
+	inFence := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+		if m := mdHeading.FindStringSubmatch(line); m != nil {
+			if len(out) > 0 && strings.TrimSpace(out[len(out)-1]) != "" {
+				out = append(out, "")
+			}
+			out = append(out, strings.ToUpper(m[1]), "")
+			continue
+		}
+		if strings.TrimSpace(line) == "" && len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+			continue
+		}
+		line = mdLink.ReplaceAllString(line, "$1")
+		line = mdCode.ReplaceAllString(line, "$1")
+		line = mdStrong.ReplaceAllString(line, "$2")
+		line = mdEmphasis.ReplaceAllString(line, "$1$2")
+		out = append(out, line)
+	}
+	return out
+}
@@ -0,0 +1,148 @@
+package viewer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// thumbStripRows is the height of the thumbnail strip: the thumbnails and,
+// on the last row, their page numbers.
+const thumbStripRows = 5
+
+// thumbCacheMax bounds the cached thumbnails; the cache is emptied when it
+// grows past this, as it only needs to hold one strip's worth.
+const thumbCacheMax = 64
+
+// thumbStripHeight returns how many rows at the top of the screen the
+// thumbnail strip takes, or 0 when it is off. It is only shown on terminals
+// with a graphics protocol, for documents with rendered pages, and when
+// the terminal is tall enough to leave room for the page.
+func (d *DocumentViewer) thumbStripHeight(termHeight int) int {
+	if !d.thumbnails || termHeight < 3*thumbStripRows {
+		return 0
+	}
+	if _, err := d.doc.Bound(d.textPages[d.currentPage]); err != nil {
+		return 0
+	}
+	if d.useBlockArt(d.detectTerminalType()) {
+		return 0
+	}
+	return thumbStripRows
+}
+
+// drawThumbStrip draws a row of page thumbnails, centered on the current
+// page, across rows rows of the screen from row top, with the current one
+// framed.
+func (d *DocumentViewer) drawThumbStrip(termWidth, top, rows int) {
+	termType := d.detectTerminalType()
+	cellW, cellH := d.getTerminalCellSize()
+	const border = 3
+	thumbH := max(int(float64(rows-1)*cellH)-2*border, 8)
+	slotW := thumbH*3/4 + 2*border
+	gap := max(int(cellW), 4)
+
+	n := (int(float64(termWidth)*cellW) + gap) / (slotW + gap)
+	n = min(max(n, 1), len(d.textPages))
+	start := min(max(d.currentPage-n/2, 0), len(d.textPages)-n)
+
+	stripW := n*slotW + (n-1)*gap
+	stripH := thumbH + 2*border
+	bg := color.RGBA{255, 255, 255, 255}
+	if d.darkMode != "" {
+		bg = color.RGBA{30, 30, 30, 255}
+	}
+	strip := image.NewRGBA(image.Rect(0, 0, stripW, stripH))
+	draw.Draw(strip, strip.Bounds(), &image.Uniform{bg}, image.Point{}, draw.Src)
+
+	centers := make([]int, n)
+	for i := range n {
+		x := i * (slotW + gap)
+		centers[i] = x + slotW/2
+		if start+i == d.currentPage {
+			frame := image.Rect(x, 0, x+slotW, stripH)
+			draw.Draw(strip, frame, &image.Uniform{color.RGBA{255, 170, 0, 255}}, image.Point{}, draw.Src)
+		}
+		slot := image.Rect(x+border, border, x+slotW-border, stripH-border)
+		thumb := d.thumbnail(d.textPages[start+i], thumbH)
+		if thumb == nil {
+			draw.Draw(strip, slot, &image.Uniform{color.RGBA{128, 128, 128, 255}}, image.Point{}, draw.Src)
+			continue
+		}
+		// Fit the page into its slot, keeping its shape.
+		b := thumb.Bounds()
+		w, h := slot.Dx(), slot.Dy()
+		if b.Dx()*h > b.Dy()*w {
+			h = max(b.Dy()*w/b.Dx(), 1)
+		} else {
+			w = max(b.Dx()*h/b.Dy(), 1)
+		}
+		dst := image.Rect(0, 0, w, h).Add(image.Pt(slot.Min.X+(slot.Dx()-w)/2, slot.Min.Y+(slot.Dy()-h)/2))
+		xdraw.ApproxBiLinear.Scale(strip, dst, d.adjustColors(thumb), b, draw.Src, nil)
+	}
+
+	if err := os.MkdirAll(d.tempDir, 0o755); err != nil {
+		return
+	}
+	imagePath := filepath.Join(d.tempDir, "thumbs.png")
+	file, err := os.Create(imagePath)
+	if err != nil {
+		return
+	}
+	err = png.Encode(file, strip)
+	file.Close()
+	defer os.Remove(imagePath)
+	if err != nil {
+		return
+	}
+
+	widthChars := int(float64(stripW)/cellW) + 1
+	offset := max((termWidth-widthChars)/2, 0)
+	fmt.Printf("\033[%d;1H", top)
+	d.renderWithTermImg(imagePath, rows-1, offset, widthChars, stripW, stripH, termType)
+
+	// Page numbers under the thumbnails, the current one in reverse video.
+	var labels strings.Builder
+	col := 0
+	for i, center := range centers {
+		label := strconv.Itoa(start + i + 1)
+		at := max(offset+int(float64(center)/cellW)-len(label)/2, col)
+		labels.WriteString(strings.Repeat(" ", at-col))
+		if start+i == d.currentPage {
+			labels.WriteString("\033[7m" + label + "\033[27m")
+		} else {
+			labels.WriteString(label)
+		}
+		col = at + len(label)
+	}
+	fmt.Printf("\033[%d;1H\033[2K\033[2m%s\033[0m", top+rows-1, labels.String())
+}
+
+// thumbnail renders doc page pageNum about height pixels tall, caching the
+// result. It returns nil if the page can't be rendered.
+func (d *DocumentViewer) thumbnail(pageNum, height int) image.Image {
+	if img, ok := d.thumbCache[pageNum]; ok && img != nil && img.Bounds().Dy() >= height {
+		return img
+	}
+	rect, err := d.doc.Bound(pageNum)
+	if err != nil || rect.Dy() <= 0 {
+		return nil
+	}
+	img, err := d.doc.ImageDPI(pageNum, float64(height)/float64(rect.Dy())*72)
+	if err != nil {
+		return nil
+	}
+	if d.thumbCache == nil || len(d.thumbCache) >= thumbCacheMax {
+		d.thumbCache = map[int]image.Image{}
+	}
+	d.thumbCache[pageNum] = img
+	return img
+}
@@ -2,20 +2,29 @@ package viewer
 
 import (
 	"crypto/md5"
+	"encoding/json"
 	"fmt"
 	"image"
+	"io"
+	"math"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/gen2brain/go-fitz"
+	"github.com/blacktop/go-termimg"
+	"golang.org/x/term"
 
 	"pdf-cli/internal/config"
-	"pdf-cli/internal/layout"
 	"pdf-cli/internal/terminal"
+	"pdf-cli/internal/theme"
 )
 
 // Chapter represents a document chapter/section from the ToC.
@@ -27,11 +36,18 @@ type Chapter struct {
 
 // DocumentViewer is the main document viewing engine.
 type DocumentViewer struct {
-	doc         *fitz.Document
+	doc         DocumentBackend
 	currentPage int
 	textPages   []int
+	allPages    bool   // no page looked like content, so textPages holds every page
+	scanning    bool   // textPages holds every page until the background scan is done
+	scanGen     int    // bumped when textPages is rebuilt, to drop stale scans
+	scanResult  chan contentScan // delivers the background scan
+	blankPages  map[int]bool     // pages checked for content while scanning
 	path        string
+	docPath     string // the file the backends read: path, or the EPUB a Kindle book was converted to
 	fileType    string // "pdf" or "epub"
+	language    string // declared document language, e.g. "de-DE"; "" if unknown
 	tempDir     string // for storing temporary image files
 	forceMode   string // "", "text", or "image" - override auto-detection
 	fitMode      string  // "auto", "height", "width"
@@ -58,20 +74,100 @@ type DocumentViewer struct {
 	cropRight      float64 // fraction to cut from right edge
 	chapters       []Chapter // table of contents / chapter list
 	currentChapter int       // index into chapters for current position
+	chapterStarts  []int     // EPUB only: first pages of the top-level chapters, ascending
+	bookmarks      []int     // sorted 0-indexed document pages
+	maxDPI         float64   // render DPI ceiling (0 = terminal default)
+	graphicsProbed bool      // whether graphics protocol support has been detected
+	blockArt       bool      // render images as ANSI half-blocks (no graphics protocol)
+	protocol       termimg.Protocol // graphics protocol to force (Unsupported = let termimg pick)
+	lineOffset     int       // first visible line when scrolling within a text page
+	pageLines      int       // reflowed line count of the displayed text page
+	visibleLines   int       // text lines that fit on screen for the displayed page
+	imageScroll    bool      // the displayed page is a fit-width image scrolled like text
+	onImagePage    bool      // the displayed page is a single page image (zoomable)
+	zoom           float64   // image page magnification (<= 1: whole page)
+	panX, panY     float64   // center of the zoomed viewport, as fractions of the page
+	textMargin     int       // left margin for text pages, in columns (0–20)
+	lineSpacing    int       // 1: single, 2: blank line between text lines
+	theme          string    // color theme name, shared across documents
+	columns        bool      // read multi-column pages column by column
+	tables         bool      // lay out detected tables as grids
+	keepHyphens    bool      // don't rejoin words hyphenated across lines
+	reflowMode     string    // "": detect, "reflow": always join lines, "preserve": keep line breaks
+	brightness     float64   // image page brightness shift, -0.5 to 0.5
+	contrast       float64   // image page contrast, -0.5 to 0.8
+	grayscale      bool      // render image pages without color
+	thumbnails     bool      // show the page thumbnail strip (graphics terminals)
+	thumbCache     map[int]image.Image // thumbnails by document page
+	ruler          bool      // reading ruler: dim all but the focus line on text pages
+	rulerLine      int       // the ruler's focus line, indexing the page's reflowed lines
+	rulerStops     []int     // non-blank reflowed lines of the displayed page, where the ruler stops
+	shownLines     []string  // reflowed lines of the displayed text page
+	selecting      bool      // selection mode: the selected lines are shown in reverse video
+	selStart, selEnd int     // ends of the selection, indexing shownLines; j/k move selEnd
+	speech         *exec.Cmd // text-to-speech reading of a page, nil if none was started
+	speechDone     chan struct{} // closed when the speech program exits
+	speaker        speaker   // the program speech runs
+	autoScroll     bool      // advance a screen every autoScrollEvery
+	autoScrollEvery time.Duration // auto-scroll interval, set with + and - while it runs
+	mouse          bool      // mouse wheel reporting enabled in settings
+	keys           keyMap    // key remapping from settings
+	count          int       // pending vim-style count typed before a command
+	renderErr      error     // why the last page image failed to render, if it did
+	password       string    // last password entered for an encrypted document
+	cellOverrideW  float64   // cell width from settings (0 = detect)
+	cellOverrideH  float64   // cell height from settings (0 = detect)
+	includeBlank   bool      // skip blank page detection and show every page
+	inkThreshold   float64   // share of pixels that must be ink for a page to count (0 = default)
+	startPage      int       // 1-based document page to open at (0 = first page)
+	jumpHistory    []int     // document pages jumped away from, most recent last
+	jumped         bool      // the last key jumped rather than paged
+	clock          bool      // show the time and battery level in the status bar
+	liveStatus     bool      // redraw the status bar every second, not just on render
+	maxTextWidth   int       // widest text column on text pages (0 = terminal width)
+	statusFormat   []statusPart // the status bar layout, parsed from the status_format setting
+	matchStyle     string    // SGR sequence for search matches in page text
+	redrawStatus   func()    // reprints the status bar on screen (nil if there is none)
+	spinner        int       // frame of the busy spinner in a live status bar
+	battery        string    // cached battery segment of the clock
+	batteryAt      time.Time // when battery was read
+
+	docMu        sync.Mutex // guards doc against the background word counter
+	wordCount    int        // words across all content pages, once counted
+	wordsCounted bool       // wordCount is ready
+
+	tabs    *tabSet     // the documents open in this session, d among them
+	started bool        // Run has shown this document
 }
 
+// keyPoll is how long Run's key reader waits on stdin before checking
+// whether it should stop.
+const keyPoll = 50 * time.Millisecond
+
+// IncludeBlank, set by --include-blank, makes every viewer show blank pages
+// too, as the include_blank setting does.
+var IncludeBlank bool
+
 // NewDocumentViewer creates a new viewer for the given file path.
 func NewDocumentViewer(path string) *DocumentViewer {
 	ext := strings.ToLower(filepath.Ext(path))
 	fileType := strings.TrimPrefix(ext, ".")
+	if path == StdinPath {
+		fileType = stdinType
+	}
 
 	tempDir := filepath.Join(os.TempDir(), fmt.Sprintf("docviewer_%d", time.Now().UnixNano()))
 
 	absPath, _ := filepath.Abs(path)
-	cfg := config.Load(absPath)
+	cfg := config.Defaults()
+	if path != StdinPath {
+		cfg = config.Load(absPath)
+	}
+	settings := config.LoadSettings()
 
 	dv := &DocumentViewer{
 		path:          path,
+		docPath:       path,
 		fileType:      fileType,
 		tempDir:       tempDir,
 		fitMode:       cfg.FitMode,
@@ -84,16 +180,45 @@ func NewDocumentViewer(path string) *DocumentViewer {
 		cropBottom:    cfg.CropBottom,
 		cropLeft:      cfg.CropLeft,
 		cropRight:     cfg.CropRight,
+		bookmarks:     cfg.Bookmarks,
+		maxDPI:        cfg.MaxDPI,
+		textMargin:    cfg.TextMargin,
+		lineSpacing:   cfg.LineSpacing,
+		theme:         settings.Theme,
+		mouse:         settings.Mouse,
+		clock:         settings.Clock,
+		liveStatus:    settings.LiveStatus,
+		maxTextWidth:  max(settings.MaxTextWidth, 0),
+		statusFormat:  parseStatusFormat(settings.StatusFormat),
+		autoScrollEvery: time.Duration(settings.AutoScroll * float64(time.Second)),
+		matchStyle:    theme.Background(settings.HighlightColor, "\033[43;30m", "\033[7m"),
+		keys:          newKeyMap(settings.KeyProfile, settings.Keys),
+		cellOverrideW: settings.CellWidth,
+		cellOverrideH: settings.CellHeight,
+		includeBlank:  IncludeBlank || settings.IncludeBlank,
+		inkThreshold:  settings.BlankThreshold,
+		columns:       cfg.Columns,
+		tables:        cfg.Tables,
+		keepHyphens:   cfg.KeepHyphens,
+		reflowMode:    cfg.ReflowMode,
+		brightness:    cfg.Brightness,
+		contrast:      cfg.Contrast,
+		grayscale:     cfg.Grayscale,
+		thumbnails:    cfg.Thumbnails,
+		ruler:         cfg.Ruler,
 		isReflowable:  fileType == "html" || fileType == "htm",
 	}
+	dv.tabs = &tabSet{docs: []*DocumentViewer{dv}, crashed: make(chan any, 1)}
 
 	return dv
 }
 
-// Open opens the document and prepares it for viewing.
+// Open opens the document and prepares it for viewing. If it fails, the
+// document and any temporary files are already released.
 func (d *DocumentViewer) Open() error {
-	doc, err := fitz.New(d.path)
+	doc, err := d.openDocument()
 	if err != nil {
+		d.Close()
 		return fmt.Errorf("error opening %s: %v", d.fileType, err)
 	}
 	d.doc = doc
@@ -102,27 +227,150 @@ func (d *DocumentViewer) Open() error {
 		d.applyHTMLLayout()
 	}
 
-	if info, err := os.Stat(d.path); err == nil {
+	if info, err := os.Stat(d.path); err == nil && !d.piped() {
 		d.lastModTime = info.ModTime()
 	}
 
-	d.findContentPages()
+	if d.canReopen() && !d.includeBlank {
+		d.startContentScan()
+	} else {
+		d.findContentPages()
+	}
 	if len(d.textPages) == 0 {
-		return fmt.Errorf("no pages with extractable content found")
+		d.Close()
+		return fmt.Errorf("document has no pages")
 	}
 
+	if d.fileType == "epub" {
+		d.language = documentLanguage(d.docPath, d.fileType, "")
+	}
 	d.loadChapters()
+	go d.countWords(d.doc, d.textPages)
 
 	return nil
 }
 
-// Run runs the main viewer loop. Returns true if user wants to go back to file picker.
+// Close releases the document and removes its temporary files. Run closes
+// it itself; Close is for callers that use the viewer without running the
+// interactive loop.
+func (d *DocumentViewer) Close() {
+	d.docMu.Lock()
+	defer d.docMu.Unlock()
+	if d.doc != nil {
+		d.doc.Close()
+		d.doc = nil
+	}
+	d.cleanup()
+}
+
+// countWords totals the words on pages of doc in the background. It gives up
+// if the document is closed or replaced by a reload, which starts a new count.
+func (d *DocumentViewer) countWords(doc DocumentBackend, pages []int) {
+	defer d.guard()
+	words := 0
+	for _, pageNum := range pages {
+		d.docMu.Lock()
+		if d.doc != doc {
+			d.docMu.Unlock()
+			return
+		}
+		text, err := doc.Text(pageNum)
+		d.docMu.Unlock()
+		if err == nil {
+			words += len(strings.Fields(text))
+		}
+	}
+
+	d.docMu.Lock()
+	if d.doc == doc {
+		d.wordCount, d.wordsCounted = words, true
+	}
+	d.docMu.Unlock()
+}
+
+// wordStats returns the document word count and whether it is ready yet.
+func (d *DocumentViewer) wordStats() (int, bool) {
+	d.docMu.Lock()
+	defer d.docMu.Unlock()
+	return d.wordCount, d.wordsCounted
+}
+
+// DumpText writes the raw text of each content page to w, each preceded by a
+// "--- page N ---" separator. first and last are 1-based document page numbers
+// bounding the output; zero leaves that end open.
+func (d *DocumentViewer) DumpText(w io.Writer, first, last int) error {
+	d.waitForContentScan()
+	for _, pageNum := range d.textPages {
+		if (first > 0 && pageNum+1 < first) || (last > 0 && pageNum+1 > last) {
+			continue
+		}
+		text, err := d.doc.Text(pageNum)
+		if err != nil {
+			return fmt.Errorf("page %d: %v", pageNum+1, err)
+		}
+		if _, err := fmt.Fprintf(w, "--- page %d ---\n%s\n", pageNum+1, strings.TrimRight(text, "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DumpJSON is DumpText as a JSON array with one object per content page:
+// {"page": N, "text": "...", "hasImage": bool}, where hasImage says whether
+// the page has a rendered image worth showing. Pages are written as they are
+// extracted rather than collected first, so large documents stream.
+func (d *DocumentViewer) DumpJSON(w io.Writer, first, last int) error {
+	d.waitForContentScan()
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	sep := "\n"
+	for _, pageNum := range d.textPages {
+		if (first > 0 && pageNum+1 < first) || (last > 0 && pageNum+1 > last) {
+			continue
+		}
+		text, err := d.doc.Text(pageNum)
+		if err != nil {
+			return fmt.Errorf("page %d: %v", pageNum+1, err)
+		}
+		obj, err := json.Marshal(struct {
+			Page     int    `json:"page"`
+			Text     string `json:"text"`
+			HasImage bool   `json:"hasImage"`
+		}{pageNum + 1, strings.TrimRight(text, "\n"), d.pageHasVisualContent(pageNum)})
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s  %s", sep, obj); err != nil {
+			return err
+		}
+		sep = ",\n"
+	}
+	end := "\n]\n"
+	if sep == "\n" {
+		end = "]\n"
+	}
+	_, err := io.WriteString(w, end)
+	return err
+}
+
+// StartAt makes Run open the document at the given 1-based document page, or
+// the next one with content if that page is skipped. A page past the end is
+// clamped to the last page; StartAt returns the page it settled on. Call it
+// after Open.
+func (d *DocumentViewer) StartAt(page int) int {
+	d.startPage = max(min(page, d.doc.NumPage()), 1)
+	return d.startPage
+}
+
+// Run runs the main viewer loop over the document and any opened in tabs
+// alongside it. Returns true if user wants to go back to file picker.
 func (d *DocumentViewer) Run() bool {
-	defer d.doc.Close()
-	defer d.cleanup()
-	defer d.saveConfig()
+	defer d.tabs.closeAll()
+	d.tabs.running.Store(true)
+	defer d.tabs.running.Store(false)
 
-	d.cellWidth, d.cellHeight = terminal.DetectCellSize()
+	d.cellWidth, d.cellHeight = d.detectCellSize()
 
 	oldState, err := terminal.SetRawMode()
 	if err != nil {
@@ -132,23 +380,34 @@ func (d *DocumentViewer) Run() bool {
 	defer terminal.RestoreTerminal(oldState)
 	fmt.Print("\033[?25l")
 	defer fmt.Print("\033[?25h")
+	if d.mouse {
+		terminal.EnableMouse()
+		defer terminal.DisableMouse()
+	}
 
-	d.currentPage = 0
-
-	inputChan := make(chan byte, 1)
+	// The key reader polls so that it can stop when Run returns; one left
+	// blocked on stdin would take the next keys meant for the file picker.
+	// Run waits for it to finish before handing the terminal back.
+	inputChan := make(chan terminal.Key, 1)
 	stopChan := make(chan struct{})
-	defer close(stopChan)
-
-	pageChan := make(chan int, 1)
-
-	d.setupFIFO()
-	defer d.cleanupFIFO()
-
-	go d.fifoListener(pageChan, stopChan)
+	readerDone := make(chan struct{})
+	defer func() {
+		close(stopChan)
+		<-readerDone
+	}()
 
 	go func() {
+		defer close(readerDone)
 		for {
-			char := terminal.ReadSingleChar()
+			select {
+			case <-stopChan:
+				return
+			default:
+			}
+			char, ok := terminal.ReadKeyTimeout(keyPoll)
+			if !ok {
+				continue
+			}
 			select {
 			case <-stopChan:
 				return
@@ -157,18 +416,94 @@ func (d *DocumentViewer) Run() bool {
 		}
 	}()
 
+	// Redraw immediately when the terminal is resized instead of waiting
+	// for the next key press.
+	resizeChan := make(chan os.Signal, 1)
+	signal.Notify(resizeChan, syscall.SIGWINCH)
+	defer signal.Stop(resizeChan)
+
+	// Each tab runs the loop until the user quits or switches to another,
+	// which picks up the terminal's cell size and theme from it.
+	tab := d
+	for {
+		next := tab.view(inputChan, resizeChan)
+		if next == nil {
+			return tab.wantBack
+		}
+		next.cellWidth, next.cellHeight, next.theme = tab.cellWidth, tab.cellHeight, tab.theme
+		tab = next
+	}
+}
+
+// view runs the viewer loop for one tab. It returns the tab to switch to,
+// or nil when the user quits or goes back to the file picker.
+func (d *DocumentViewer) view(inputChan <-chan terminal.Key, resizeChan <-chan os.Signal) *DocumentViewer {
+	defer d.recoverScreen()
+
+	if !d.started {
+		d.started = true
+		if absPath, err := filepath.Abs(d.path); err == nil && !d.piped() {
+			config.AddRecent(absPath)
+		}
+		d.currentPage = 0
+		if d.startPage > 0 {
+			d.jumpToPage(d.startPage)
+		}
+		d.skipBlankPages(1)
+	}
+
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+
+	pageChan := make(chan int, 1)
+
+	d.setupFIFO()
+	defer d.cleanupFIFO()
+
+	go d.fifoListener(pageChan, stopChan)
+
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
 
+	// A nil channel never fires, so without live_status nothing is redrawn
+	// between keys.
+	var statusTick <-chan time.Time
+	if d.liveStatus {
+		t := time.NewTicker(time.Second)
+		defer t.Stop()
+		statusTick = t.C
+	}
+
+	// Auto-scroll ticks only while it is on: the ticker is stopped here and
+	// reset whenever a key turns it on or changes the interval.
+	autoTicker := time.NewTicker(time.Hour)
+	autoTicker.Stop()
+	defer autoTicker.Stop()
+
 	d.displayCurrentPage()
 
 	for {
 		select {
 		case char := <-inputChan:
+			prevPage := d.currentPage
+			fromPage := d.textPages[d.currentPage]
+			d.jumped = false
+			autoScroll, autoScrollEvery := d.autoScroll, d.autoScrollEvery
 			action := d.handleInput(char)
+			if d.autoScroll != autoScroll || d.autoScrollEvery != autoScrollEvery {
+				if d.autoScroll {
+					autoTicker.Reset(d.autoScrollEvery)
+				} else {
+					autoTicker.Stop()
+				}
+			}
+			if d.count > 0 {
+				// Still typing a count; nothing has changed yet.
+				continue
+			}
 			if action == 1 {
 				fmt.Print("\033[2J\033[H")
-				return d.wantBack
+				return nil
 			}
 			switch action {
 			case -1:
@@ -180,20 +515,118 @@ func (d *DocumentViewer) Run() bool {
 			case -4:
 				d.showDebugInfo(inputChan)
 			case -5:
-				d.showChapterList(inputChan)
+				d.showTableOfContents(inputChan)
+			case -6:
+				d.showBookmarks(inputChan)
+			case -7:
+				d.showInfo(inputChan)
+			case -8:
+				d.followLink(inputChan)
+			case -9:
+				d.exportCurrentPage(inputChan)
+			case -10:
+				d.exportText(inputChan)
+			case -11:
+				d.goToDocPage(inputChan)
+			case -12:
+				d.listImages(inputChan)
+			case -13:
+				d.toggleSpeech(inputChan)
+			case -14:
+				d.selectLines(inputChan)
+			case -15:
+				if next := d.tabs.next(d, 1); next != d {
+					return next
+				}
+			case -16:
+				if next := d.tabs.next(d, -1); next != d {
+					return next
+				}
+			case -17:
+				if next := d.openTab(inputChan); next != nil {
+					return next
+				}
+			}
+			if d.currentPage != prevPage {
+				// Prompts and menus (search, goto, ToC, bookmarks, links)
+				// only ever move by jumping.
+				if d.jumped || action < 0 {
+					d.pushJump(fromPage)
+				}
+				d.skipBlankPages(d.currentPage - prevPage)
+				d.resetPageView()
 			}
 			d.displayCurrentPage()
 		case page := <-pageChan:
+			prevPage := d.currentPage
+			fromPage := d.textPages[d.currentPage]
 			d.jumpToPage(page)
+			if d.currentPage != prevPage {
+				d.pushJump(fromPage)
+				d.skipBlankPages(d.currentPage - prevPage)
+				d.resetPageView()
+			}
+			d.displayCurrentPage()
+		case scan := <-d.scanResult:
+			d.applyContentScan(scan)
 			d.displayCurrentPage()
 		case <-ticker.C:
 			if d.checkAndReload() {
 				d.displayCurrentPage()
 			}
+		case <-statusTick:
+			d.refreshStatus()
+		case <-autoTicker.C:
+			if !d.autoAdvance() {
+				d.autoScroll = false
+				autoTicker.Stop()
+			}
+			d.displayCurrentPage()
+		case <-resizeChan:
+			d.refreshCellSize()
+			d.displayCurrentPage()
+		case r := <-d.tabs.crashed:
+			panic(r)
 		}
 	}
 }
 
+// recoverScreen, deferred in Run, runs first when the viewer panics. It
+// ends a synchronized update left open mid-frame, which would keep the
+// terminal from showing anything, and clears the half-drawn page. The
+// panic then goes on with the page that caused it, and the rest of Run's
+// deferred calls restore the terminal before the trace is printed.
+func (d *DocumentViewer) recoverScreen() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	fmt.Print("\033[?2026l\033[0m\033[2J\033[H")
+	page := d.currentPage
+	if page >= 0 && page < len(d.textPages) {
+		page = d.textPages[page]
+	}
+	panic(fmt.Sprintf("%v\n\n(viewing page %d of %s)", r, page+1, d.path))
+}
+
+// guard, deferred in a background goroutine, hands a panic in it to Run,
+// which re-raises it so the terminal is restored before the program dies.
+// This holds for every tab's goroutines, not just the one on screen.
+// Outside Run the panic goes on as usual.
+func (d *DocumentViewer) guard() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if !d.tabs.running.Load() {
+		panic(r)
+	}
+	select {
+	case d.tabs.crashed <- fmt.Sprintf("%v\n\n%s", r, debug.Stack()):
+	default:
+	}
+}
+
 func (d *DocumentViewer) cleanup() {
 	if d.tempDir != "" {
 		os.RemoveAll(d.tempDir)
@@ -202,7 +635,7 @@ func (d *DocumentViewer) cleanup() {
 
 func (d *DocumentViewer) saveConfig() {
 	absPath, err := filepath.Abs(d.path)
-	if err != nil {
+	if err != nil || d.piped() {
 		return
 	}
 
@@ -217,6 +650,19 @@ func (d *DocumentViewer) saveConfig() {
 		CropBottom:    d.cropBottom,
 		CropLeft:      d.cropLeft,
 		CropRight:     d.cropRight,
+		MaxDPI:        d.maxDPI,
+		Bookmarks:     d.bookmarks,
+		TextMargin:    d.textMargin,
+		LineSpacing:   d.lineSpacing,
+		Columns:       d.columns,
+		Tables:        d.tables,
+		KeepHyphens:   d.keepHyphens,
+		ReflowMode:    d.reflowMode,
+		Brightness:    d.brightness,
+		Contrast:      d.contrast,
+		Grayscale:     d.grayscale,
+		Thumbnails:    d.thumbnails,
+		Ruler:         d.ruler,
 	}
 
 	config.Save(absPath, cfg)
@@ -294,7 +740,7 @@ func (d *DocumentViewer) jumpToPage(page int) {
 
 func (d *DocumentViewer) checkAndReload() bool {
 	info, err := os.Stat(d.path)
-	if err != nil {
+	if err != nil || d.piped() {
 		return false
 	}
 
@@ -315,26 +761,17 @@ func (d *DocumentViewer) checkAndReload() bool {
 		}
 
 		savedPage := d.currentPage
-		savedStderr, _ := syscall.Dup(2)
-		devNull, _ := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
-		if devNull != nil && savedStderr != -1 {
-			syscall.Dup2(int(devNull.Fd()), 2)
-		}
-
-		doc, openErr := fitz.New(d.path)
-
-		if savedStderr != -1 {
-			syscall.Dup2(savedStderr, 2)
-			syscall.Close(savedStderr)
-		}
-		if devNull != nil {
-			devNull.Close()
-		}
+		restoreStderr := terminal.SilenceStderr()
+		doc, openErr := d.openDocument()
+		restoreStderr()
 
 		if openErr != nil {
 			return false
 		}
 
+		d.docMu.Lock()
+		defer d.docMu.Unlock()
+
 		oldDoc := d.doc
 		oldPages := d.textPages
 		oldPage := d.currentPage
@@ -355,6 +792,9 @@ func (d *DocumentViewer) checkAndReload() bool {
 		}
 
 		oldDoc.Close()
+		d.thumbCache = nil
+		d.wordCount, d.wordsCounted = 0, false
+		go d.countWords(d.doc, d.textPages)
 
 		if savedPage >= len(d.textPages) {
 			savedPage = len(d.textPages) - 1
@@ -369,10 +809,54 @@ func (d *DocumentViewer) checkAndReload() bool {
 	return false
 }
 
+// openDocument opens d.path with the backend for its file type. Plain text is
+// split into pages of about one screen. Kindle books are converted to EPUB
+// first, and again on every reload, after which they are viewed as EPUBs.
+func (d *DocumentViewer) openDocument() (DocumentBackend, error) {
+	if isKindle(d.fileType) || d.docPath != d.path {
+		epub, err := convertKindle(d.path, d.tempDir)
+		if err != nil {
+			return nil, err
+		}
+		d.docPath, d.fileType = epub, "epub"
+	}
+	_, termHeight := d.getTerminalSize()
+	return openBackend(d.docPath, d.fileType, termHeight-2, d.askPassword)
+}
+
+// askPassword reads the password for an encrypted document with echo off.
+// The last password entered is tried first, so reloads don't prompt again;
+// once the viewer is running it never prompts, since it owns the terminal.
+func (d *DocumentViewer) askPassword(attempt int) (string, bool) {
+	if attempt == 1 && d.password != "" {
+		return d.password, true
+	}
+	if d.doc != nil || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", false
+	}
+	if attempt > 1 {
+		fmt.Fprintln(os.Stderr, "Incorrect password, try again.")
+	}
+	name := filepath.Base(d.path)
+	if d.piped() {
+		name = "standard input"
+	}
+	fmt.Fprintf(os.Stderr, "Password for %s: ", name)
+	pw, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil || len(pw) == 0 {
+		return "", false
+	}
+	d.password = string(pw)
+	return d.password, true
+}
+
 // applyHTMLLayout calls fz_layout_document to set page width for HTML files.
 func (d *DocumentViewer) applyHTMLLayout() {
 	h := float64(d.htmlPageWidth) * 1.414
-	layout.LayoutDocument(d.doc, float64(d.htmlPageWidth), h, 12)
+	if fb, ok := d.doc.(layouter); ok {
+		fb.layout(float64(d.htmlPageWidth), h, 12)
+	}
 	d.findContentPages()
 }
 
@@ -404,131 +888,327 @@ func (d *DocumentViewer) adjustHTMLZoom(delta int) {
 	}
 }
 
+// findContentPages fills textPages with the pages that have content,
+// checking them all before it returns. It cancels any background scan.
 func (d *DocumentViewer) findContentPages() {
-	d.textPages = []int{}
-	for i := 0; i < d.doc.NumPage(); i++ {
-		hasContent := false
+	d.scanGen++
+	d.scanning = false
+	n := d.doc.NumPage()
+	if d.includeBlank {
+		d.textPages = allPages(n)
+		return
+	}
 
-		text, err := d.doc.Text(i)
-		if err == nil && len(strings.Fields(strings.TrimSpace(text))) >= 3 {
-			hasContent = true
-		}
+	// go-fitz serializes all calls on a document, so sharing d.doc between
+	// workers gains nothing; each extra worker opens its own handle.
+	docs := []DocumentBackend{d.doc}
+	if n >= minParallelPages && d.canReopen() {
+		docs = append(docs, d.openHandles(contentWorkers-1, d.askPassword)...)
+		defer closeAll(docs[1:])
+	}
+	d.setContentPages(d.contentPages(docs, n), n)
+}
 
-		if !hasContent {
-			if rect, err := d.doc.Bound(i); err == nil && rect.Dx() > 50 && rect.Dy() > 50 {
-				if d.pageHasVisualContent(i) {
-					hasContent = true
-				}
-			}
+// toggleAllPages switches between showing every page, blank ones included,
+// and only the pages with content, staying on the same document page or
+// the next one shown.
+func (d *DocumentViewer) toggleAllPages() {
+	current := d.textPages[d.currentPage]
+	d.includeBlank = !d.includeBlank
+	if d.canReopen() && !d.includeBlank {
+		d.startContentScan()
+	} else {
+		d.findContentPages()
+	}
+	d.jumpToPage(current + 1)
+}
+
+// setContentPages makes pages the viewer's page list. Rather than refuse a
+// document where nothing passed the checks (blank pages, only tiny images),
+// it shows every page and says so.
+func (d *DocumentViewer) setContentPages(pages []int, numPages int) {
+	d.allPages = len(pages) == 0
+	if d.allPages {
+		pages = allPages(numPages)
+	}
+	d.textPages = pages
+}
+
+// contentScan is the result of a background content page scan.
+type contentScan struct {
+	gen   int   // scanGen when the scan started; stale results are dropped
+	pages []int // content pages, or nil if the scan couldn't run
+	ok    bool
+}
+
+// startContentScan lets a large document open at once: every page is shown
+// to begin with, and the content pages are found in the background on
+// handles of the scan's own. Run swaps them in when the scan is done; until
+// then blank pages are skipped as the reader reaches them.
+func (d *DocumentViewer) startContentScan() {
+	n := d.doc.NumPage()
+	d.textPages = allPages(n)
+	d.scanGen++
+	d.scanning = true
+	d.blankPages = map[int]bool{}
+	gen := d.scanGen
+
+	// The scan mustn't touch the viewer's state, which a reload may change
+	// under it; give it the password it needs up front.
+	pw := d.password
+	password := func(attempt int) (string, bool) { return pw, attempt == 1 && pw != "" }
+	result := make(chan contentScan, 1)
+	d.scanResult = result
+	go func() {
+		defer d.guard()
+		docs := d.openHandles(contentWorkers, password)
+		defer closeAll(docs)
+		if len(docs) == 0 {
+			result <- contentScan{gen: gen}
+			return
 		}
+		result <- contentScan{gen: gen, pages: d.contentPages(docs, n), ok: true}
+	}()
+}
 
-		if hasContent {
-			d.textPages = append(d.textPages, i)
+// applyContentScan swaps in the result of a background scan, keeping the
+// reader on the same document page, or the next one with content.
+func (d *DocumentViewer) applyContentScan(scan contentScan) {
+	if scan.gen != d.scanGen {
+		return
+	}
+	d.scanning = false
+	d.scanResult = nil
+	if !scan.ok {
+		return
+	}
+	n := d.doc.NumPage()
+	pages := scan.pages[:0:0]
+	for _, p := range scan.pages {
+		if p < n {
+			pages = append(pages, p)
 		}
 	}
+	current := d.textPages[d.currentPage]
+	d.setContentPages(pages, n)
+	d.jumpToPage(current + 1)
 }
 
-func (d *DocumentViewer) pageHasVisualContent(pageNum int) bool {
-	img, err := d.doc.Image(pageNum)
-	if err != nil {
-		return false
+// waitForContentScan finishes a background scan, for callers that need the
+// exact page list.
+func (d *DocumentViewer) waitForContentScan() {
+	if d.scanning {
+		d.applyContentScan(<-d.scanResult)
 	}
+}
 
-	bounds := img.Bounds()
-	if bounds.Dx() < 50 || bounds.Dy() < 50 {
-		return false
+// skipBlankPages moves on from a blank page in the direction the reader was
+// going (dir > 0 forward) while the background scan is still running. If
+// the rest of the document that way is blank it stays put.
+func (d *DocumentViewer) skipBlankPages(dir int) {
+	if !d.scanning {
+		return
+	}
+	step := 1
+	if dir < 0 {
+		step = -1
+	}
+	for i := d.currentPage; i >= 0 && i < len(d.textPages); i += step {
+		page := d.textPages[i]
+		blank, seen := d.blankPages[page]
+		if !seen {
+			blank = !d.pageHasContent(d.doc, page)
+			d.blankPages[page] = blank
+		}
+		if !blank {
+			d.currentPage = i
+			return
+		}
 	}
-
-	return d.hasNonBlankContent(img)
 }
 
-func (d *DocumentViewer) hasNonBlankContent(img image.Image) bool {
-	bounds := img.Bounds()
-
-	sampleRate := 10
-	nonWhiteThreshold := 20
-	whiteThreshold := uint8(240)
+// pageTotal formats the page count for the status line, marked as
+// approximate while blank pages may still be dropped.
+func (d *DocumentViewer) pageTotal() string {
+	if d.scanning {
+		return fmt.Sprintf("~%d", len(d.textPages))
+	}
+	return strconv.Itoa(len(d.textPages))
+}
 
-	nonWhitePixels := 0
+// canReopen reports whether the document can be opened again for parallel
+// or background scanning. Plain text and comics are cheap to check on the
+// one handle, and HTML handles would need the same layout applied.
+func (d *DocumentViewer) canReopen() bool {
+	return !d.isReflowable && d.fileType != "cbz" && d.fileType != "txt" && d.fileType != "md"
+}
 
-	for y := bounds.Min.Y; y < bounds.Max.Y; y += sampleRate {
-		for x := bounds.Min.X; x < bounds.Max.X; x += sampleRate {
-			c := img.At(x, y)
-			r, g, b, a := c.RGBA()
+// openHandles opens up to count more handles on the document.
+func (d *DocumentViewer) openHandles(count int, password func(attempt int) (string, bool)) []DocumentBackend {
+	var docs []DocumentBackend
+	for len(docs) < count {
+		doc, err := openBackend(d.docPath, d.fileType, 0, password)
+		if err != nil {
+			break
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
 
-			r8 := uint8(r >> 8)
-			g8 := uint8(g >> 8)
-			b8 := uint8(b >> 8)
-			a8 := uint8(a >> 8)
+func closeAll(docs []DocumentBackend) {
+	for _, doc := range docs {
+		doc.Close()
+	}
+}
 
-			if a8 < 10 {
-				continue
+// contentPages checks pages 0 through n-1 with one worker per handle in
+// docs and returns those with content, in order.
+func (d *DocumentViewer) contentPages(docs []DocumentBackend, n int) []int {
+	hasContent := make([]bool, n)
+	pages := make(chan int)
+	var wg sync.WaitGroup
+	for _, doc := range docs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range pages {
+				// Recovering per page keeps the feed below from
+				// stalling if a page panics.
+				func() {
+					defer d.guard()
+					hasContent[i] = d.pageHasContent(doc, i)
+				}()
 			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		pages <- i
+	}
+	close(pages)
+	wg.Wait()
 
-			if r8 < whiteThreshold || g8 < whiteThreshold || b8 < whiteThreshold {
-				nonWhitePixels++
-
-				if nonWhitePixels >= nonWhiteThreshold {
-					return true
-				}
-			}
+	var content []int
+	for i, ok := range hasContent {
+		if ok {
+			content = append(content, i)
 		}
 	}
+	return content
+}
+
+func allPages(n int) []int {
+	pages := make([]int, n)
+	for i := range pages {
+		pages[i] = i
+	}
+	return pages
+}
+
+// contentWorkers bounds how many pages findContentPages analyzes at once,
+// and minParallelPages is the page count below which it doesn't bother.
+var contentWorkers = min(runtime.NumCPU(), 8)
+
+const minParallelPages = 16
 
-	colorVariance := d.checkColorVariance(img)
-	if colorVariance > 100 {
+// pageHasContent reports whether page i of doc has at least a few words of
+// text or, failing that, visible marks.
+func (d *DocumentViewer) pageHasContent(doc DocumentBackend, i int) bool {
+	// Every comic page is an image; don't decode them all up front.
+	if d.fileType == "cbz" {
 		return true
 	}
+	if text, err := doc.Text(i); err == nil && len(strings.Fields(strings.TrimSpace(text))) >= 3 {
+		return true
+	}
+	rect, err := doc.Bound(i)
+	return err == nil && rect.Dx() > 50 && rect.Dy() > 50 && d.hasVisualContent(doc, i)
+}
 
-	return nonWhitePixels >= nonWhiteThreshold
+// pageHasVisualContent is hasVisualContent on the viewer's own document.
+func (d *DocumentViewer) pageHasVisualContent(pageNum int) bool {
+	return d.hasVisualContent(d.doc, pageNum)
 }
 
-func (d *DocumentViewer) checkColorVariance(img image.Image) float64 {
+func (d *DocumentViewer) hasVisualContent(doc DocumentBackend, pageNum int) bool {
+	img, err := doc.Image(pageNum)
+	if err != nil {
+		return false
+	}
+
 	bounds := img.Bounds()
+	if bounds.Dx() < 50 || bounds.Dy() < 50 {
+		return false
+	}
+
+	return d.hasNonBlankContent(img)
+}
 
-	sampleRate := 20
-	var rSum, gSum, bSum uint64
-	var rSumSq, gSumSq, bSumSq uint64
-	sampleCount := 0
+// defaultBlankThreshold is the share of sampled pixels that must stand out
+// from the page background for the page to count as content.
+const defaultBlankThreshold = 0.002
 
-	for y := bounds.Min.Y; y < bounds.Max.Y; y += sampleRate {
-		for x := bounds.Min.X; x < bounds.Max.X; x += sampleRate {
-			c := img.At(x, y)
-			r, g, b, a := c.RGBA()
+// inkDelta is how far, in any channel, a pixel must be from the background
+// color to count as ink.
+const inkDelta = 24
 
-			if uint8(a>>8) < 10 {
+// hasNonBlankContent reports whether img shows anything. The background is
+// taken to be the most common color rather than white, so text on a tinted
+// slide counts while scanner specks on white don't; a page that is a solid
+// color darker than near-white counts too. About 10,000 pixels are sampled
+// whatever the image size, and the ink threshold is a share of them.
+func (d *DocumentViewer) hasNonBlankContent(img image.Image) bool {
+	bounds := img.Bounds()
+	step := max(1, int(math.Sqrt(float64(bounds.Dx()*bounds.Dy())/10000)))
+
+	type rgb struct{ r, g, b uint8 }
+	var samples []rgb
+	var bins [4096]int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a>>8 < 10 {
 				continue
 			}
-
-			r8 := uint8(r >> 8)
-			g8 := uint8(g >> 8)
-			b8 := uint8(b >> 8)
-
-			rSum += uint64(r8)
-			gSum += uint64(g8)
-			bSum += uint64(b8)
-
-			rSumSq += uint64(r8) * uint64(r8)
-			gSumSq += uint64(g8) * uint64(g8)
-			bSumSq += uint64(b8) * uint64(b8)
-
-			sampleCount++
+			c := rgb{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)}
+			samples = append(samples, c)
+			bins[int(c.r>>4)<<8|int(c.g>>4)<<4|int(c.b>>4)]++
 		}
 	}
-
-	if sampleCount < 10 {
-		return 0
+	if len(samples) < 10 {
+		return false
 	}
 
-	rMean := float64(rSum) / float64(sampleCount)
-	gMean := float64(gSum) / float64(sampleCount)
-	bMean := float64(bSum) / float64(sampleCount)
+	bg := 0
+	for i, n := range bins {
+		if n > bins[bg] {
+			bg = i
+		}
+	}
+	// Center of the background's bin, per channel.
+	bgR, bgG, bgB := bg>>8<<4|8, bg>>4&15<<4|8, bg&15<<4|8
+	if bgR < 240 || bgG < 240 || bgB < 240 {
+		return true
+	}
 
-	rVar := float64(rSumSq)/float64(sampleCount) - rMean*rMean
-	gVar := float64(gSumSq)/float64(sampleCount) - gMean*gMean
-	bVar := float64(bSumSq)/float64(sampleCount) - bMean*bMean
+	ink := 0
+	for _, c := range samples {
+		if abs(int(c.r)-bgR) > inkDelta || abs(int(c.g)-bgG) > inkDelta || abs(int(c.b)-bgB) > inkDelta {
+			ink++
+		}
+	}
+	threshold := d.inkThreshold
+	if threshold <= 0 {
+		threshold = defaultBlankThreshold
+	}
+	return ink >= 3 && float64(ink) >= threshold*float64(len(samples))
+}
 
-	return rVar + gVar + bVar
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
 }
 
 // Terminal helper methods (thin wrappers with caching)
@@ -554,10 +1234,22 @@ func (d *DocumentViewer) getTerminalCellSize() (float64, float64) {
 		return d.cellWidth, d.cellHeight
 	}
 
-	d.cellWidth, d.cellHeight = terminal.DetectCellSize()
+	d.cellWidth, d.cellHeight = d.detectCellSize()
 	return d.cellWidth, d.cellHeight
 }
 
+// detectCellSize returns the cell size from the environment or settings if
+// either sets one, and otherwise asks the terminal.
+func (d *DocumentViewer) detectCellSize() (float64, float64) {
+	if w, h := terminal.EnvCellSize(); w > 0 && h > 0 {
+		return w, h
+	}
+	if d.cellOverrideW > 0 && d.cellOverrideH > 0 {
+		return d.cellOverrideW, d.cellOverrideH
+	}
+	return terminal.DetectCellSize()
+}
+
 func (d *DocumentViewer) refreshCellSize() {
 	d.cellWidth = 0
 	d.cellHeight = 0
@@ -0,0 +1,59 @@
+package viewer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// page returns a 100x100 page, sampled in full, filled with bg and with ink
+// pixels of color fg along its top rows.
+func page(bg, fg color.Color, ink int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	for i := range ink {
+		img.Set(i%100, i/100, fg)
+	}
+	return img
+}
+
+func TestHasNonBlankContent(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	black := color.RGBA{0, 0, 0, 255}
+	paper := color.RGBA{250, 248, 242, 255}
+	slide := color.RGBA{232, 240, 252, 255}
+	navy := color.RGBA{20, 30, 80, 255}
+
+	// At the default threshold a 100x100 page needs 0.2% of its 10,000
+	// samples, 20 pixels, to stand out from the background.
+	tests := []struct {
+		name      string
+		img       image.Image
+		threshold float64
+		want      bool
+	}{
+		{"blank white", page(white, black, 0), 0, false},
+		{"scanner specks", page(white, black, 5), 0, false},
+		{"faint gray", page(white, color.RGBA{235, 235, 235, 255}, 2000), 0, false},
+		{"text on white", page(white, black, 500), 0, true},
+		{"blank off-white paper", page(paper, black, 0), 0, false},
+		{"text on off-white paper", page(paper, black, 500), 0, true},
+		{"text on light slide", page(slide, navy, 500), 0, true},
+		{"solid light slide", page(slide, navy, 0), 0, true},
+		{"solid dark", page(navy, white, 0), 0, true},
+		{"below threshold", page(white, black, 19), 0, false},
+		{"at threshold", page(white, black, 20), 0, true},
+		{"custom threshold below", page(white, black, 99), 0.01, false},
+		{"custom threshold at", page(white, black, 100), 0.01, true},
+		{"transparent", image.NewRGBA(image.Rect(0, 0, 100, 100)), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &DocumentViewer{inkThreshold: tt.threshold}
+			if got := d.hasNonBlankContent(tt.img); got != tt.want {
+				t.Errorf("hasNonBlankContent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}